@@ -2,6 +2,8 @@ package text
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Alignment int
@@ -11,22 +13,26 @@ const (
 	AlignLeft
 	AlignCenter
 	AlignRight
+	AlignJustify
 )
 
 // LineAlign align the given line as asked and apply the needed padding to match the given
 // lineWidth, while ignoring the terminal escape sequences.
 // If the given lineWidth is too small to fit the given line, it's returned without
 // padding, overflowing lineWidth.
-func LineAlign(line string, lineWidth int, align Alignment) string {
+// Except for NoAlign, the tabs are expanded into spaces (see TabSize).
+func LineAlign(line string, lineWidth int, align Alignment, opts ...TabOption) string {
 	switch align {
 	case NoAlign:
 		return line
 	case AlignLeft:
-		return LineAlignLeft(line, lineWidth)
+		return LineAlignLeft(line, lineWidth, opts...)
 	case AlignCenter:
-		return LineAlignCenter(line, lineWidth)
+		return LineAlignCenter(line, lineWidth, opts...)
 	case AlignRight:
-		return LineAlignRight(line, lineWidth)
+		return LineAlignRight(line, lineWidth, opts...)
+	case AlignJustify:
+		return LineAlignJustify(line, lineWidth, opts...)
 	}
 	panic("unknown alignment")
 }
@@ -34,16 +40,16 @@ func LineAlign(line string, lineWidth int, align Alignment) string {
 // LineAlignLeft align the given line on the left while ignoring the terminal escape sequences.
 // If the given lineWidth is too small to fit the given line, it's returned without
 // padding, overflowing lineWidth.
-func LineAlignLeft(line string, lineWidth int) string {
-	return TrimSpace(line)
+func LineAlignLeft(line string, lineWidth int, opts ...TabOption) string {
+	return TrimSpace(expandTabs(line, 0, tabWidth(opts)))
 }
 
 // LineAlignCenter align the given line on the center and apply the needed left
 // padding, while ignoring the terminal escape sequences.
 // If the given lineWidth is too small to fit the given line, it's returned without
 // padding, overflowing lineWidth.
-func LineAlignCenter(line string, lineWidth int) string {
-	trimmed := TrimSpace(line)
+func LineAlignCenter(line string, lineWidth int, opts ...TabOption) string {
+	trimmed := TrimSpace(expandTabs(line, 0, tabWidth(opts)))
 	totalPadLen := lineWidth - Len(trimmed)
 	if totalPadLen < 0 {
 		totalPadLen = 0
@@ -56,8 +62,8 @@ func LineAlignCenter(line string, lineWidth int) string {
 // padding to match the given lineWidth, while ignoring the terminal escape sequences.
 // If the given lineWidth is too small to fit the given line, it's returned without
 // padding, overflowing lineWidth.
-func LineAlignRight(line string, lineWidth int) string {
-	trimmed := TrimSpace(line)
+func LineAlignRight(line string, lineWidth int, opts ...TabOption) string {
+	trimmed := TrimSpace(expandTabs(line, 0, tabWidth(opts)))
 	padLen := lineWidth - Len(trimmed)
 	if padLen < 0 {
 		padLen = 0
@@ -65,3 +71,75 @@ func LineAlignRight(line string, lineWidth int) string {
 	pad := strings.Repeat(" ", padLen)
 	return pad + trimmed
 }
+
+// LineAlignJustify align the given line on both sides by distributing the
+// needed padding in the gaps between words, while ignoring the terminal escape
+// sequences. If the line has no spaces, as is common with CJK text, the padding
+// is distributed between the wide characters instead.
+// If the line has no gap to expand, it's aligned on the left.
+// If the given lineWidth is too small to fit the given line, it's returned without
+// padding, overflowing lineWidth.
+func LineAlignJustify(line string, lineWidth int, opts ...TabOption) string {
+	trimmed := TrimSpace(expandTabs(line, 0, tabWidth(opts)))
+	extra := lineWidth - Len(trimmed)
+	if extra <= 0 {
+		return trimmed
+	}
+
+	cleaned, escapes := ExtractTermEscapes(trimmed)
+
+	// gaps are the rune index where padding can be inserted: after each run of
+	// spaces, or between wide characters if there is no space at all
+	var gaps []int
+	var wideGaps []int
+	pos := 0
+	prevSpace := false
+	prevWide := false
+	walkGraphemes(cleaned, func(cluster string, _ bool, width int) bool {
+		r, _ := utf8.DecodeRuneInString(cluster)
+		space := unicode.IsSpace(r)
+		if prevSpace && !space {
+			gaps = append(gaps, pos)
+		}
+		if pos > 0 && (prevWide || width > 1) {
+			wideGaps = append(wideGaps, pos)
+		}
+		prevSpace = space
+		prevWide = width > 1
+		pos += utf8.RuneCountInString(cluster)
+		return true
+	})
+	if len(gaps) == 0 {
+		gaps = wideGaps
+	}
+	if len(gaps) == 0 {
+		return trimmed
+	}
+
+	// the first gaps get the remainder of the padding
+	var out strings.Builder
+	runes := []rune(cleaned)
+	last := 0
+	shifted := make([]EscapeItem, len(escapes))
+	copy(shifted, escapes)
+	for i, gap := range gaps {
+		padLen := extra / len(gaps)
+		if i < extra%len(gaps) {
+			padLen++
+		}
+		out.WriteString(string(runes[last:gap]))
+		out.WriteString(strings.Repeat(" ", padLen))
+		last = gap
+
+		// the padding is inserted before the escapes at the same position, so
+		// that it takes the style of the gap it expands
+		for j := range shifted {
+			if escapes[j].Pos >= gap {
+				shifted[j].Pos += padLen
+			}
+		}
+	}
+	out.WriteString(string(runes[last:]))
+
+	return ApplyTermEscapes(out.String(), shifted)
+}
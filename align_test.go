@@ -6,6 +6,13 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestLineAlign(t *testing.T) {
+	// no alignment return the line as is
+	assert.Equal(t, "  a\tb ", LineAlign("  a\tb ", 20, NoAlign))
+	assert.Equal(t, "a b", LineAlign("  a\tb ", 20, AlignLeft))
+	assert.Equal(t, "     a   b", LineAlign("a\tb", 10, AlignRight))
+}
+
 func TestLineAlignLeft(t *testing.T) {
 	cases := []struct {
 		line   string
@@ -30,9 +37,9 @@ func TestLineAlignLeft(t *testing.T) {
 		},
 		// respect escape sequences and wide chars
 		{
-			"   敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"   敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 			60,
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 		},
 	}
 	for _, tc := range cases {
@@ -44,7 +51,7 @@ func TestLineAlignLeft(t *testing.T) {
 func BenchmarkLineAlignLeft(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		LineAlignLeft("敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
+		LineAlignLeft("敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
 	}
 }
 
@@ -67,9 +74,9 @@ func TestLineAlignCenter(t *testing.T) {
 		},
 		// respect escape sequences and wide chars
 		{
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 			60,
-			" 敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			" 敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 		},
 	}
 	for _, tc := range cases {
@@ -81,7 +88,7 @@ func TestLineAlignCenter(t *testing.T) {
 func BenchmarkLineAlignCenter(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		LineAlignCenter("敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
+		LineAlignCenter("敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
 	}
 }
 
@@ -104,9 +111,15 @@ func TestLineAlignRight(t *testing.T) {
 		},
 		// respect escape sequences and wide chars
 		{
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 			60,
-			"  敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"  敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+		},
+		// tabs are expanded to the next tab stop
+		{
+			"a\tb\tc",
+			12,
+			"   a   b   c",
 		},
 	}
 	for _, tc := range cases {
@@ -118,6 +131,66 @@ func TestLineAlignRight(t *testing.T) {
 func BenchmarkLineAlignRight(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		LineAlignRight("敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
+		LineAlignRight("敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。", 60)
+	}
+}
+
+func TestLineAlignJustify(t *testing.T) {
+	cases := []struct {
+		line   string
+		width  int
+		output string
+	}{
+		{
+			"  foo bar baz ",
+			15,
+			"foo   bar   baz",
+		},
+		// the first gaps get the remainder
+		{
+			"foo bar baz",
+			14,
+			"foo   bar  baz",
+		},
+		// width too low return the same input
+		{
+			"The Lorem ipsum text is typically composed of pseudo-Latin words.",
+			10,
+			"The Lorem ipsum text is typically composed of pseudo-Latin words.",
+		},
+		// no gap to expand
+		{
+			"foobar",
+			10,
+			"foobar",
+		},
+		// respect escape sequences, the padding takes the style of the gap
+		{
+			"\x1b[4mfoo \x1b[0mbar \x1b[31mbaz\x1b[0m",
+			14,
+			"\x1b[4mfoo   \x1b[0mbar  \x1b[31mbaz\x1b[0m",
+		},
+		// CJK text without spaces
+		{
+			"一只敏捷的",
+			14,
+			"一 只 敏 捷 的",
+		},
+		{
+			"一只 A Quick 敏捷",
+			20,
+			"一只  A  Quick  敏捷",
+		},
+	}
+	for _, tc := range cases {
+		out := LineAlignJustify(tc.line, tc.width)
+		assert.Equal(t, tc.output, out)
+	}
+}
+
+func BenchmarkLineAlignJustify(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		LineAlignJustify("敏捷 A \x1b[31mquick 的狐狸 fox 跳\x1b[0m过 jumps", 60)
 	}
 }
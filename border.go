@@ -0,0 +1,60 @@
+package text
+
+// Border is a set of strings used to draw the borders of a Table or a Box.
+// Each string is expected to be one cell wide.
+type Border struct {
+	Horizontal, Vertical string
+
+	TopLeft, TopRight, BottomLeft, BottomRight string
+
+	// Junctions of the inner lines with the outer border, and between
+	// the inner lines.
+	TopJunction, BottomJunction, LeftJunction, RightJunction, Cross string
+}
+
+var (
+	// BorderASCII draw the borders with ASCII characters only.
+	BorderASCII = Border{
+		Horizontal: "-", Vertical: "|",
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		TopJunction: "+", BottomJunction: "+", LeftJunction: "+", RightJunction: "+", Cross: "+",
+	}
+
+	// BorderSingle draw the borders with single box-drawing lines.
+	BorderSingle = Border{
+		Horizontal: "─", Vertical: "│",
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		TopJunction: "┬", BottomJunction: "┴", LeftJunction: "├", RightJunction: "┤", Cross: "┼",
+	}
+
+	// BorderDouble draw the borders with double box-drawing lines.
+	BorderDouble = Border{
+		Horizontal: "═", Vertical: "║",
+		TopLeft: "╔", TopRight: "╗", BottomLeft: "╚", BottomRight: "╝",
+		TopJunction: "╦", BottomJunction: "╩", LeftJunction: "╠", RightJunction: "╣", Cross: "╬",
+	}
+
+	// BorderRounded draw the borders with single box-drawing lines and
+	// rounded corners.
+	BorderRounded = Border{
+		Horizontal: "─", Vertical: "│",
+		TopLeft: "╭", TopRight: "╮", BottomLeft: "╰", BottomRight: "╯",
+		TopJunction: "┬", BottomJunction: "┴", LeftJunction: "├", RightJunction: "┤", Cross: "┼",
+	}
+
+	// BorderHeavy draw the borders with heavy box-drawing lines.
+	BorderHeavy = Border{
+		Horizontal: "━", Vertical: "┃",
+		TopLeft: "┏", TopRight: "┓", BottomLeft: "┗", BottomRight: "┛",
+		TopJunction: "┳", BottomJunction: "┻", LeftJunction: "┣", RightJunction: "┫", Cross: "╋",
+	}
+)
+
+// styleBorder apply the given escape sequences to a piece of border, and reset
+// them after it.
+func styleBorder(style string, s string) string {
+	if style == "" || s == "" {
+		return s
+	}
+	return style + s + "\x1b[0m"
+}
@@ -0,0 +1,152 @@
+package text
+
+import (
+	"strings"
+)
+
+// Box render a block of text inside a border, while handling properly the
+// terminal escape sequences and wide characters. Each line of the text is
+// rendered on its own, so that its style doesn't leak in the border, and the
+// style of the border doesn't affect the text.
+type Box struct {
+	// Border to draw around the text, or nil to only apply the padding.
+	Border *Border
+	// BorderStyle is the escape sequences applied to the border, like "\x1b[2m".
+	BorderStyle string
+
+	// Title is displayed in the top border, or on the first line without border.
+	Title      string
+	TitleAlign Alignment
+
+	// Align is the alignment of the lines of text inside the box.
+	Align Alignment
+	// Padding is the number of spaces on each side of the text, and
+	// VerticalPadding the number of empty lines above and below it.
+	Padding         int
+	VerticalPadding int
+	// Width is the total width of the box, or 0 to fit the text. The text
+	// is wrapped to fit, and the title truncated.
+	Width int
+}
+
+// NewBox create a Box with a single line border and a padding of one space.
+func NewBox() *Box {
+	border := BorderSingle
+	return &Box{
+		Border:  &border,
+		Padding: 1,
+	}
+}
+
+// Render return the given text inside the box, with the number of lines.
+func (b *Box) Render(text string) (string, int) {
+	innerWidth := b.innerWidth(text)
+	contentWidth := innerWidth - 2*b.Padding
+	// the wrapping can't go below the widest character
+	minWidth := maxGraphemeWidth(text)
+	if minWidth < 1 {
+		minWidth = 1
+	}
+	if contentWidth < minWidth {
+		contentWidth = minWidth
+		innerWidth = contentWidth + 2*b.Padding
+	}
+
+	var vertical string
+	if b.Border != nil {
+		vertical = styleBorder(b.BorderStyle, b.Border.Vertical)
+	}
+	padding := strings.Repeat(" ", b.Padding)
+	emptyLine := vertical + strings.Repeat(" ", innerWidth) + vertical
+
+	var lines []string
+
+	if b.Border != nil {
+		lines = append(lines, b.top(innerWidth))
+	} else if b.Title != "" {
+		lines = append(lines, cellLines(b.Title, innerWidth, TableColumn{Align: b.TitleAlign, Truncate: true})[0])
+	}
+
+	for i := 0; i < b.VerticalPadding; i++ {
+		lines = append(lines, emptyLine)
+	}
+
+	for _, line := range cellLines(text, contentWidth, TableColumn{Align: b.Align}) {
+		var sb strings.Builder
+		sb.WriteString(vertical)
+		sb.WriteString(padding)
+		sb.WriteString(line)
+		if lineLen := Len(line); lineLen < contentWidth {
+			sb.WriteString(strings.Repeat(" ", contentWidth-lineLen))
+		}
+		sb.WriteString(padding)
+		sb.WriteString(vertical)
+		lines = append(lines, sb.String())
+	}
+
+	for i := 0; i < b.VerticalPadding; i++ {
+		lines = append(lines, emptyLine)
+	}
+
+	if b.Border != nil {
+		lines = append(lines, styleBorder(b.BorderStyle,
+			b.Border.BottomLeft+strings.Repeat(b.Border.Horizontal, innerWidth)+b.Border.BottomRight))
+	}
+
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// innerWidth return the width inside the border, including the padding.
+func (b *Box) innerWidth(text string) int {
+	if b.Width > 0 {
+		if b.Border != nil {
+			return b.Width - Len(b.Border.Vertical)*2
+		}
+		return b.Width
+	}
+
+	result := MaxLineLen(text) + 2*b.Padding
+	if b.Title != "" {
+		// room for the title surrounded by spaces, and a piece of border
+		// on each side
+		titleWidth := Len(b.Title)
+		if b.Border != nil {
+			titleWidth += 4
+		}
+		if titleWidth > result {
+			result = titleWidth
+		}
+	}
+	return result
+}
+
+// top return the top border, with the title.
+func (b *Box) top(innerWidth int) string {
+	if b.Title == "" || innerWidth < 3 {
+		return styleBorder(b.BorderStyle,
+			b.Border.TopLeft+strings.Repeat(b.Border.Horizontal, innerWidth)+b.Border.TopRight)
+	}
+
+	title := cellLines(b.Title, innerWidth-2, TableColumn{Truncate: true})[0]
+	title = " " + title + " "
+
+	free := innerWidth - Len(title)
+	before := 0
+	switch b.TitleAlign {
+	case AlignCenter:
+		before = free / 2
+	case AlignRight:
+		before = free
+		if free > 0 {
+			before--
+		}
+	default:
+		if free > 0 {
+			before = 1
+		}
+	}
+
+	return styleBorder(b.BorderStyle, b.Border.TopLeft+strings.Repeat(b.Border.Horizontal, before)) +
+		title +
+		styleBorder(b.BorderStyle, strings.Repeat(b.Border.Horizontal, free-before)+b.Border.TopRight)
+}
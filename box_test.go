@@ -0,0 +1,177 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBox(t *testing.T) {
+	cases := []struct {
+		name   string
+		box    func() *Box
+		text   string
+		output string
+	}{
+		{
+			"simple",
+			NewBox,
+			"foo\nbarbaz",
+			"┌────────┐\n" +
+				"│ foo    │\n" +
+				"│ barbaz │\n" +
+				"└────────┘",
+		},
+		{
+			"border sets",
+			func() *Box {
+				b := NewBox()
+				b.Border = &BorderDouble
+				b.Padding = 0
+				return b
+			},
+			"foo",
+			"╔═══╗\n" +
+				"║foo║\n" +
+				"╚═══╝",
+		},
+		{
+			"custom border",
+			func() *Box {
+				b := NewBox()
+				b.Border = &Border{Horizontal: "~", Vertical: "!", TopLeft: "/", TopRight: "\\", BottomLeft: "\\", BottomRight: "/"}
+				return b
+			},
+			"foo",
+			"/~~~~~\\\n" +
+				"! foo !\n" +
+				"\\~~~~~/",
+		},
+		{
+			"narrow width with wide characters",
+			func() *Box {
+				b := NewBox()
+				b.Width = 4
+				return b
+			},
+			"敏捷",
+			"┌────┐\n" +
+				"│ 敏 │\n" +
+				"│ 捷 │\n" +
+				"└────┘",
+		},
+		{
+			"wide characters",
+			func() *Box {
+				b := NewBox()
+				b.Border = &BorderHeavy
+				return b
+			},
+			"一只\n狐狸跳过",
+			"┏━━━━━━━━━━┓\n" +
+				"┃ 一只     ┃\n" +
+				"┃ 狐狸跳过 ┃\n" +
+				"┗━━━━━━━━━━┛",
+		},
+		{
+			"title widen the box",
+			func() *Box {
+				b := NewBox()
+				b.Title = "Title"
+				return b
+			},
+			"foo",
+			"┌─ Title ─┐\n" +
+				"│ foo     │\n" +
+				"└─────────┘",
+		},
+		{
+			"title alignment",
+			func() *Box {
+				b := NewBox()
+				b.Border = &BorderASCII
+				b.Title = "T"
+				b.TitleAlign = AlignCenter
+				return b
+			},
+			"foo bar baz",
+			"+----- T -----+\n" +
+				"| foo bar baz |\n" +
+				"+-------------+",
+		},
+		{
+			"fixed width, wrapping and truncated title",
+			func() *Box {
+				b := NewBox()
+				b.Border = &BorderRounded
+				b.Title = "A very long title here"
+				b.TitleAlign = AlignRight
+				b.Align = AlignCenter
+				b.Width = 16
+				b.VerticalPadding = 1
+				return b
+			},
+			"The quick brown fox jumps",
+			"╭ A very long… ╮\n" +
+				"│              │\n" +
+				"│  The quick   │\n" +
+				"│  brown fox   │\n" +
+				"│    jumps     │\n" +
+				"│              │\n" +
+				"╰──────────────╯",
+		},
+		{
+			"no border",
+			func() *Box {
+				b := NewBox()
+				b.Border = nil
+				b.Title = "Title"
+				b.Align = AlignRight
+				return b
+			},
+			"a\nbcd",
+			"Title\n" +
+				"   a \n" +
+				" bcd ",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			output, nbLines := c.box().Render(c.text)
+			assert.Equal(t, c.output, output)
+			assert.Equal(t, strings.Count(c.output, "\n")+1, nbLines)
+		})
+	}
+}
+
+func TestNewBoxBorder(t *testing.T) {
+	b := NewBox()
+	b.Border.Vertical = "!"
+
+	// the default border is not shared
+	assert.Equal(t, "│", BorderSingle.Vertical)
+	assert.Equal(t, "│", NewBox().Border.Vertical)
+}
+
+func TestBoxStyle(t *testing.T) {
+	b := NewBox()
+	b.Title = "\x1b[1mTitle"
+	b.BorderStyle = "\x1b[2m"
+
+	output, _ := b.Render("foo\n\x1b[31m一只 red")
+
+	expected := "\x1b[2m┌─\x1b[0m \x1b[1mTitle\x1b[0m \x1b[2m──┐\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m foo      \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m \x1b[31m一只 red\x1b[0m \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m└──────────┘\x1b[0m"
+	assert.Equal(t, expected, output)
+
+	// each line render on its own
+	for _, line := range strings.Split(output, "\n") {
+		var state EscapeState
+		state.Witness(line)
+		assert.True(t, state.IsZero(), "line %q", line)
+	}
+}
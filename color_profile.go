@@ -0,0 +1,297 @@
+package text
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColorProfile is the set of colors a terminal is able to display.
+type ColorProfile int
+
+const (
+	// ProfileTrueColor support the 24-bit RGB colors.
+	ProfileTrueColor ColorProfile = iota
+	// Profile256 support the 256 colors palette.
+	Profile256
+	// Profile16 support the 8 basic colors and their bright variants.
+	Profile16
+	// Profile8 support only the 8 basic colors.
+	Profile8
+	// ProfileMonochrome doesn't support colors. The other attributes, like
+	// bold or underline, are kept.
+	ProfileMonochrome
+)
+
+// Downsample rewrite the colors of the SGR sequences of a string into the
+// nearest ones supported by the given profile, as perceived by a human.
+// With ProfileMonochrome, the colors are removed, and so are the SGR sequences
+// left empty. Other escape sequences and text are left untouched.
+func Downsample(s string, profile ColorProfile) string {
+	if profile == ProfileTrueColor {
+		return s
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		n := escapeLen(s[i:])
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			out.WriteString(s[i : i+size])
+			i += size
+			continue
+		}
+
+		seq := s[i : i+n]
+		if params, ok := sgrParams(seq); ok && params != "" {
+			seq = downsampleSGR(params, profile)
+		}
+		out.WriteString(seq)
+		i += n
+	}
+	return out.String()
+}
+
+// downsampleSGR return the SGR sequence with the given parameters, with the
+// colors downsampled, or nothing if no parameter is left.
+func downsampleSGR(params string, profile ColorProfile) string {
+	split := strings.Split(params, ";")
+	var codes []string
+
+	for len(split) > 0 {
+		if strings.Contains(split[0], ":") {
+			codes = append(codes, downsampleSubParams(split[0], profile)...)
+			split = split[1:]
+			continue
+		}
+
+		code, err := strconv.Atoi(split[0])
+		if err != nil {
+			// not understood, keeping the rest as is
+			codes = append(codes, split...)
+			break
+		}
+
+		switch {
+		case (code >= 30 && code <= 37) || (code >= 90 && code <= 97) ||
+			(code >= 40 && code <= 47) || (code >= 100 && code <= 107):
+			codes = append(codes, downsampleIndex(code, profile)...)
+			split = split[1:]
+
+		case code == 39 || code == 49 || code == 59:
+			if profile != ProfileMonochrome {
+				codes = append(codes, split[0])
+			}
+			split = split[1:]
+
+		case code == 38 || code == 48 || code == 58:
+			n := 0
+			switch {
+			case len(split) >= 3 && split[1] == "5":
+				n = 3
+			case len(split) >= 5 && split[1] == "2":
+				n = 5
+			}
+			var c Color
+			if n > 0 {
+				c = parseColor(code, split[1:n])
+			}
+			if c == nil {
+				// broken sequence
+				codes = append(codes, split...)
+				split = nil
+				break
+			}
+			codes = append(codes, downsampleColor(c, profile)...)
+			split = split[n:]
+
+		default:
+			codes = append(codes, split[0])
+			split = split[1:]
+		}
+	}
+
+	if len(codes) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// downsampleSubParams downsample a SGR parameter with colon separated
+// sub-parameters, like "38:2::255:0:0", keeping the colon form.
+func downsampleSubParams(param string, profile ColorProfile) []string {
+	sub := strings.Split(param, ":")
+	switch sub[0] {
+	case "38", "48", "58":
+	default:
+		return []string{param}
+	}
+
+	ground, _ := strconv.Atoi(sub[0])
+	c := parseColor(ground, sub[1:])
+	if c == nil {
+		return []string{param}
+	}
+
+	codes := downsampleColor(c, profile)
+	if len(codes) > 1 {
+		return []string{strings.Join(codes, ":")}
+	}
+	return codes
+}
+
+// downsampleColor downsample an extended color.
+func downsampleColor(c Color, profile ColorProfile) []string {
+	switch c := c.(type) {
+	case *Color256:
+		return downsample256(c.ground, c.Index, profile)
+	case *ColorRGB:
+		return downsampleRGB(c.ground, c.R, c.G, c.B, profile)
+	}
+	return nil
+}
+
+// downsampleIndex downsample a basic color code (30-37, 40-47, 90-97, 100-107).
+func downsampleIndex(code int, profile ColorProfile) []string {
+	switch profile {
+	case ProfileMonochrome:
+		return nil
+	case Profile8:
+		if code >= 90 {
+			code -= 60
+		}
+	}
+	return []string{strconv.Itoa(code)}
+}
+
+// downsample256 downsample a color of the 256 colors palette, for the foreground
+// (ground = 38), the background (ground = 48) or the underline (ground = 58).
+func downsample256(ground int, index int, profile ColorProfile) []string {
+	if index < 0 || index > 255 {
+		return nil
+	}
+	switch profile {
+	case Profile256:
+		return Color256{ground: ground, Index: index}.Codes()
+	case Profile16:
+		if index >= 16 {
+			index = nearestColor(palette[index], 0, 16)
+		}
+		return paletteCodes(ground, index)
+	case Profile8:
+		if index >= 8 {
+			index = nearestColor(palette[index], 0, 8)
+		}
+		return paletteCodes(ground, index)
+	}
+	return nil
+}
+
+// downsampleRGB downsample a RGB color, for the foreground (ground = 38), the
+// background (ground = 48) or the underline (ground = 58).
+func downsampleRGB(ground int, r, g, b int, profile ColorProfile) []string {
+	c := toOklab(r, g, b)
+	switch profile {
+	case Profile256:
+		// the first 16 colors are usually customized, avoiding them
+		return Color256{ground: ground, Index: nearestColor(c, 16, 256)}.Codes()
+	case Profile16:
+		return paletteCodes(ground, nearestColor(c, 0, 16))
+	case Profile8:
+		return paletteCodes(ground, nearestColor(c, 0, 8))
+	}
+	return nil
+}
+
+// paletteCodes return the SGR codes of one of the first 16 colors of the palette.
+// The underline color doesn't have basic codes, the 256 colors form is used.
+func paletteCodes(ground int, index int) []string {
+	if ground == 58 {
+		return Color256{ground: ground, Index: index}.Codes()
+	}
+	base := ground - 8 // 30 or 40
+	if index >= 8 {
+		base += 60
+		index -= 8
+	}
+	return []string{strconv.Itoa(base + index)}
+}
+
+// oklab is a color in the Oklab perceptual color space, where the euclidean
+// distance between two colors match the perceived difference.
+type oklab struct {
+	L, A, B float64
+}
+
+// toOklab convert a sRGB color to Oklab.
+func toOklab(r, g, b int) oklab {
+	linear := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	lr, lg, lb := linear(r), linear(g), linear(b)
+
+	l := math.Cbrt(0.4122214708*lr + 0.5363325363*lg + 0.0514459929*lb)
+	m := math.Cbrt(0.2119034982*lr + 0.6806995451*lg + 0.1073969566*lb)
+	s := math.Cbrt(0.0883024619*lr + 0.2817188376*lg + 0.6299787005*lb)
+
+	return oklab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// nearestColor return the index in [from, to) of the palette color nearest to c.
+func nearestColor(c oklab, from, to int) int {
+	best := from
+	bestDist := math.Inf(1)
+	for i := from; i < to; i++ {
+		p := palette[i]
+		dist := (c.L-p.L)*(c.L-p.L) + (c.A-p.A)*(c.A-p.A) + (c.B-p.B)*(c.B-p.B)
+		if dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// palette is the 256 colors palette in Oklab.
+var palette = func() [256]oklab {
+	var result [256]oklab
+	for i := range result {
+		result[i] = toOklab(color256RGB(i))
+	}
+	return result
+}()
+
+// basicColors is the RGB value of the first 16 colors of the palette, with
+// the xterm default values.
+var basicColors = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// color256RGB return the RGB value of a color of the 256 colors palette.
+func color256RGB(index int) (r, g, b int) {
+	switch {
+	case index < 16:
+		c := basicColors[index]
+		return c[0], c[1], c[2]
+	case index < 232:
+		// 6x6x6 color cube
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		i := index - 16
+		return levels[i/36], levels[i/6%6], levels[i%6]
+	default:
+		// grayscale ramp
+		v := 8 + 10*(index-232)
+		return v, v, v
+	}
+}
@@ -0,0 +1,57 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownsample(t *testing.T) {
+	cases := []struct {
+		input   string
+		profile ColorProfile
+		output  string
+	}{
+		// true color is untouched
+		{"\x1b[38;2;255;100;0mfoo\x1b[0m", ProfileTrueColor, "\x1b[38;2;255;100;0mfoo\x1b[0m"},
+
+		// 256 colors
+		{"\x1b[38;2;255;0;0mfoo\x1b[0m", Profile256, "\x1b[38;5;196mfoo\x1b[0m"},
+		{"\x1b[1;48;2;128;128;128;4mfoo", Profile256, "\x1b[1;48;5;244;4mfoo"},
+		{"\x1b[38;5;123;31mfoo", Profile256, "\x1b[38;5;123;31mfoo"},
+
+		// 16 colors
+		{"\x1b[38;2;255;0;0mfoo", Profile16, "\x1b[91mfoo"},
+		{"\x1b[48;2;0;0;139mfoo", Profile16, "\x1b[44mfoo"},
+		{"\x1b[38;5;9mfoo", Profile16, "\x1b[91mfoo"},
+		{"\x1b[38;5;34mfoo", Profile16, "\x1b[32mfoo"},
+		{"\x1b[48;5;250mfoo", Profile16, "\x1b[47mfoo"},
+		{"\x1b[1;95;39mfoo", Profile16, "\x1b[1;95;39mfoo"},
+
+		// 8 colors
+		{"\x1b[38;2;255;0;0mfoo", Profile8, "\x1b[31mfoo"},
+		{"\x1b[95;104mfoo", Profile8, "\x1b[35;44mfoo"},
+		{"\x1b[38;5;15mfoo", Profile8, "\x1b[37mfoo"},
+
+		// no color
+		{"\x1b[1;31mfoo\x1b[0m", ProfileMonochrome, "\x1b[1mfoo\x1b[0m"},
+		{"\x1b[38;5;123mfoo\x1b[39m", ProfileMonochrome, "foo"},
+		{"\x1b[48;2;1;2;3;3mfoo\x1b[m", ProfileMonochrome, "\x1b[3mfoo\x1b[m"},
+
+		// colon forms and underline colors
+		{"\x1b[38:2::255:0:0;4:3mfoo", Profile256, "\x1b[38:5:196;4:3mfoo"},
+		{"\x1b[48:5:9mfoo", Profile16, "\x1b[101mfoo"},
+		{"\x1b[58;2;255;0;0mfoo\x1b[59m", Profile16, "\x1b[58;5;9mfoo\x1b[59m"},
+		{"\x1b[58:5:200;1mfoo\x1b[59m", ProfileMonochrome, "\x1b[1mfoo"},
+
+		// other sequences and broken ones are untouched
+		{"\x1b]8;;http://example.com\x1b\\\x1b[31m一只\x1b[0m\x1b]8;;\x1b\\", Profile8,
+			"\x1b]8;;http://example.com\x1b\\\x1b[31m一只\x1b[0m\x1b]8;;\x1b\\"},
+		{"\x1b[2Jfoo", ProfileMonochrome, "\x1b[2Jfoo"},
+		{"\x1b[1;38;2;1mfoo", Profile16, "\x1b[1;38;2;1mfoo"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, Downsample(c.input, c.profile), "input %q profile %d", c.input, c.profile)
+	}
+}
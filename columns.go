@@ -0,0 +1,79 @@
+package text
+
+import (
+	"strings"
+)
+
+// Column is a block of text to render next to others with JoinColumns().
+type Column struct {
+	Text string
+	// Width is the width of the column. The text is wrapped to fit.
+	// A Width of 0 or less means the width of the longest line of the text.
+	Width int
+	// Opts are the options used to wrap the text, like with Wrap().
+	Opts []WrapOption
+}
+
+// JoinColumns render blocks of text next to each other, separated by the
+// gutter. Each block is wrapped to the width of its column, then padded to
+// the height of the tallest one.
+// The escape state is reset at the end of each line of a column and restored
+// at the start of the next one, so that the style of a column doesn't leak
+// in the gutter or the other columns.
+// Return the rendered text with the number of lines.
+func JoinColumns(gutter string, columns ...Column) (string, int) {
+	if len(columns) == 0 {
+		return "", 0
+	}
+
+	height := 0
+	widths := make([]int, len(columns))
+	blocks := make([][]string, len(columns))
+
+	for i, column := range columns {
+		widths[i] = column.Width
+		if widths[i] <= 0 {
+			widths[i] = MaxLineLen(expandTabs(column.Text, 0, allWrapOpts(column.Opts).tabWidth))
+		}
+
+		opts := append(append([]WrapOption(nil), column.Opts...), wrapIsolate())
+		wrapped, _ := Wrap(column.Text, widths[i], opts...)
+		blocks[i] = strings.Split(wrapped, "\n")
+
+		// each line start with the full escape state, that's enough to close it
+		for l, line := range blocks[i] {
+			var state EscapeState
+			state.Witness(line)
+			blocks[i][l] = line + state.ResetString()
+		}
+
+		if len(blocks[i]) > height {
+			height = len(blocks[i])
+		}
+	}
+
+	lines := make([]string, height)
+	for l := range lines {
+		var sb strings.Builder
+		for i, block := range blocks {
+			if i > 0 {
+				sb.WriteString(gutter)
+			}
+			line := ""
+			if l < len(block) {
+				line = block[l]
+			}
+			sb.WriteString(line)
+
+			// the last column doesn't need to be filled
+			if i < len(blocks)-1 {
+				if lineLen := Len(line); lineLen < widths[i] {
+					sb.WriteString(strings.Repeat(" ", widths[i]-lineLen))
+				}
+			}
+		}
+		lines[l] = sb.String()
+	}
+
+	return strings.Join(lines, "\n"), height
+}
@@ -0,0 +1,87 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinColumns(t *testing.T) {
+	cases := []struct {
+		name    string
+		gutter  string
+		columns []Column
+		output  string
+	}{
+		{
+			"no column",
+			" ",
+			nil,
+			"",
+		},
+		{
+			"single column",
+			" ",
+			[]Column{{Text: "foo bar", Width: 4}},
+			"foo\nbar",
+		},
+		{
+			"natural width",
+			" | ",
+			[]Column{{Text: "a\nbbb\ncc"}, {Text: "x"}},
+			"a   | x\n" +
+				"bbb | \n" +
+				"cc  | ",
+		},
+		{
+			"wrapping and vertical padding",
+			"  ",
+			[]Column{
+				{Text: "2021-01-01\n2021-02-03"},
+				{Text: "The quick brown fox jumps over the lazy dog", Width: 12, Opts: []WrapOption{WrapPad("> ")}},
+				{Text: "一只敏捷的狐狸", Width: 6},
+			},
+			"2021-01-01  > The quick   一只敏\n" +
+				"2021-02-03  > brown fox   捷的狐\n" +
+				"            > jumps over  狸\n" +
+				"            > the lazy    \n" +
+				"            > dog         ",
+		},
+		{
+			"escape state isolated in each column",
+			"|",
+			[]Column{
+				{Text: "\x1b[32mgreen text\x1b[0m", Width: 5},
+				{Text: "The \x1b[31mquick brown\x1b[0m fox", Width: 6},
+			},
+			"\x1b[32mgreen\x1b[0m|The\n" +
+				"\x1b[32mtext\x1b[0m |\x1b[31mquick\x1b[0m\n" +
+				"     |\x1b[31mbrown\x1b[0m\n" +
+				"     |fox",
+		},
+		{
+			"unclosed escape state",
+			" | ",
+			[]Column{
+				{Text: "\x1b[31mfoo bar", Width: 3},
+				{Text: "x\ny\nz"},
+			},
+			"\x1b[31mfoo\x1b[0m | x\n" +
+				"\x1b[31mbar\x1b[0m | y\n" +
+				"    | z",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			output, nbLines := JoinColumns(c.gutter, c.columns...)
+			assert.Equal(t, c.output, output)
+			if c.output == "" {
+				assert.Equal(t, 0, nbLines)
+			} else {
+				assert.Equal(t, strings.Count(c.output, "\n")+1, nbLines)
+			}
+		})
+	}
+}
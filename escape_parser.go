@@ -0,0 +1,157 @@
+package text
+
+import "strings"
+
+// escapeKind is the family of a terminal escape sequence, as defined by ECMA-48.
+type escapeKind int
+
+const (
+	escNone escapeKind = iota
+	// escSimple is an escape sequence made of ESC, optional intermediate bytes
+	// and a final byte, like "\x1b7" or "\x1b(B". A lone ESC falls here as well.
+	escSimple
+	// escCSI is a control sequence, like "\x1b[31m" or "\x1b[2K".
+	escCSI
+	// escOSC is an operating system command, like "\x1b]0;title\x07".
+	escOSC
+	// escString is a DCS, SOS, PM or APC control string.
+	escString
+	// escC1 is a lone C1 control character (U+0080 to U+009F).
+	escC1
+)
+
+const (
+	bel = '\x07'
+	// c1Lead is the first byte of the UTF-8 encoding of the C1 controls.
+	c1Lead = 0xc2
+)
+
+// parseEscape recognize the terminal escape sequence at the start of s, if any.
+// It returns the kind of the sequence and its length in bytes, or (escNone, 0)
+// if s doesn't start with an escape sequence.
+//
+// Both the 7-bit (ESC based) and the UTF-8 encoded C1 forms are recognized.
+// An unterminated sequence extends to the end of s.
+func parseEscape(s string) (escapeKind, int) {
+	if len(s) == 0 {
+		return escNone, 0
+	}
+
+	switch {
+	case s[0] == Escape:
+		if len(s) == 1 {
+			return escSimple, 1
+		}
+		switch s[1] {
+		case '[':
+			return escCSI, 2 + csiLen(s[2:])
+		case ']':
+			return escOSC, 2 + controlStringLen(s[2:], true)
+		case 'P', 'X', '^', '_':
+			return escString, 2 + controlStringLen(s[2:], false)
+		}
+		i := 1
+		for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+			i++
+		}
+		if i < len(s) && s[i] >= 0x30 && s[i] <= 0x7e {
+			i++
+		}
+		return escSimple, i
+
+	case s[0] == c1Lead && len(s) > 1 && s[1] >= 0x80 && s[1] <= 0x9f:
+		switch s[1] {
+		case 0x9b:
+			return escCSI, 2 + csiLen(s[2:])
+		case 0x9d:
+			return escOSC, 2 + controlStringLen(s[2:], true)
+		case 0x90, 0x98, 0x9e, 0x9f:
+			return escString, 2 + controlStringLen(s[2:], false)
+		}
+		return escC1, 2
+	}
+
+	return escNone, 0
+}
+
+// escapeLen return the length in bytes of the escape sequence at the start of s,
+// or 0 if s doesn't start with an escape sequence.
+func escapeLen(s string) int {
+	_, n := parseEscape(s)
+	return n
+}
+
+// csiLen return the length of the body of a control sequence, that is the
+// parameter bytes, the intermediate bytes and the final byte.
+// A malformed sequence is cut just before the offending byte.
+func csiLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 0x40 && c <= 0x7e:
+			return i + 1
+		case c >= 0x20 && c <= 0x3f:
+			continue
+		default:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// controlStringLen return the length of the body of a control string, including
+// its terminator. Control strings are terminated by ST (either "\x1b\\" or
+// U+009C), or by BEL for OSC. Another escape sequence cancels the string.
+func controlStringLen(s string, allowBel bool) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == bel && allowBel:
+			return i + 1
+		case s[i] == Escape:
+			if i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+			return i
+		case s[i] == c1Lead && i+1 < len(s) && s[i+1] == 0x9c:
+			return i + 2
+		}
+	}
+	return len(s)
+}
+
+// sgrParams return the parameters of a SGR sequence (CSI ... m), like "1;31"
+// for "\x1b[1;31m". The second value is false if seq is not a SGR sequence.
+func sgrParams(seq string) (string, bool) {
+	kind, n := parseEscape(seq)
+	if kind != escCSI || n != len(seq) || seq[n-1] != 'm' {
+		return "", false
+	}
+	// both introducers ("\x1b[" and U+009B) are two bytes long
+	params := seq[2 : n-1]
+	for i := 0; i < len(params); i++ {
+		c := params[i]
+		if (c < '0' || c > '9') && c != ';' && c != ':' {
+			return "", false
+		}
+	}
+	return params, true
+}
+
+// oscPayload return the content of an OSC sequence, without its introducer and
+// terminator, like "0;title" for "\x1b]0;title\x07". The second value is false
+// if seq is not an OSC sequence.
+func oscPayload(seq string) (string, bool) {
+	kind, n := parseEscape(seq)
+	if kind != escOSC || n != len(seq) {
+		return "", false
+	}
+	// both introducers ("\x1b]" and U+009D) are two bytes long
+	payload := seq[2:]
+	switch {
+	case strings.HasSuffix(payload, "\x1b\\"), strings.HasSuffix(payload, "\u009c"):
+		payload = payload[:len(payload)-2]
+	case strings.HasSuffix(payload, string(bel)):
+		payload = payload[:len(payload)-1]
+	}
+	return payload, true
+}
@@ -0,0 +1,71 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEscape(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Input  string
+		Kind   escapeKind
+		Length int
+	}{
+		{"not an escape", "foo", escNone, 0},
+		{"empty", "", escNone, 0},
+		{"SGR", "\x1b[31mfoo", escCSI, 5},
+		{"reset", "\x1b[mfoo", escCSI, 3},
+		{"erase line", "\x1b[2Kfoo", escCSI, 4},
+		{"cursor move", "\x1b[10;20Hfoo", escCSI, 8},
+		{"private mode", "\x1b[?25lfoo", escCSI, 6},
+		{"intermediate byte", "\x1b[1 qfoo", escCSI, 5},
+		{"malformed CSI", "\x1b[1\nfoo", escCSI, 3},
+		{"unterminated CSI", "\x1b[1;3", escCSI, 5},
+		{"8-bit CSI", "\u009b31mfoo", escCSI, 5},
+		{"OSC with BEL", "\x1b]0;title\x07foo", escOSC, 10},
+		{"OSC with ST", "\x1b]0;title\x1b\\foo", escOSC, 11},
+		{"OSC with 8-bit ST", "\x1b]0;title\u009cfoo", escOSC, 11},
+		{"OSC cancelled", "\x1b]0;title\x1b[31mfoo", escOSC, 9},
+		{"unterminated OSC", "\x1b]0;title", escOSC, 9},
+		{"DCS", "\x1bPq#0\x1b\\foo", escString, 7},
+		{"DCS ignore BEL", "\x1bPq\x07#0\x1b\\foo", escString, 8},
+		{"APC", "\x1b_foo\x1b\\bar", escString, 7},
+		{"save cursor", "\x1b7foo", escSimple, 2},
+		{"charset", "\x1b(Bfoo", escSimple, 3},
+		{"lone ESC", "\x1b", escSimple, 1},
+		{"ESC before text", "\x1b\x1b[31m", escSimple, 1},
+		{"C1 control", "\u0085foo", escC1, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			kind, n := parseEscape(tc.Input)
+			assert.Equal(t, tc.Kind, kind)
+			assert.Equal(t, tc.Length, n)
+		})
+	}
+}
+
+func TestSgrParams(t *testing.T) {
+	cases := []struct {
+		input  string
+		params string
+		ok     bool
+	}{
+		{"\x1b[31m", "31", true},
+		{"\x1b[m", "", true},
+		{"\x1b[1;38:2::1:2:3m", "1;38:2::1:2:3", true},
+		{"\u009b4m", "4", true},
+		{"\x1b[2K", "", false},
+		{"\x1b[>4;2m", "", false},
+		{"\x1b]0;m\x07", "", false},
+	}
+
+	for _, tc := range cases {
+		params, ok := sgrParams(tc.input)
+		assert.Equal(t, tc.params, params)
+		assert.Equal(t, tc.ok, ok)
+	}
+}
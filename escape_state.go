@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const Escape = '\x1b'
@@ -14,56 +15,69 @@ type EscapeState struct {
 	Italic     bool
 	Underlined bool
 	Blink      bool
+	FastBlink  bool
 	Reverse    bool
 	Hidden     bool
 	CrossedOut bool
+	Overlined  bool
+	Framed     bool
+	Encircled  bool
 
-	FgColor Color
-	BgColor Color
+	Superscript bool
+	Subscript   bool
+
+	// UnderlineStyle is the style of the underline, when Underlined is set.
+	UnderlineStyle UnderlineStyle
+
+	FgColor        Color
+	BgColor        Color
+	UnderlineColor Color
+
+	// Link is the active OSC 8 hyperlink, if any.
+	Link Hyperlink
 }
 
+// UnderlineStyle is the style of an underline, as set with "4:<style>".
+type UnderlineStyle int
+
+const (
+	UnderlineSingle UnderlineStyle = iota
+	UnderlineDouble
+	UnderlineCurly
+	UnderlineDotted
+	UnderlineDashed
+)
+
 type Color interface {
 	Codes() []string
 }
 
+// Witness update the state with the SGR and OSC 8 hyperlink sequences found
+// in the given string. Other escape sequences are ignored.
 func (es *EscapeState) Witness(s string) {
-	inEscape := false
-	var start int
-
-	runes := []rune(s)
-
-	for i, r := range runes {
-		if r == Escape {
-			inEscape = true
-			start = i
+	for i := 0; i < len(s); {
+		n := escapeLen(s[i:])
+		if n == 0 {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			i += size
 			continue
 		}
-		if inEscape {
-			if r == 'm' {
-				inEscape = false
-				es.witnessCode(string(runes[start+1 : i]))
-			}
-			continue
+		if params, ok := sgrParams(s[i : i+n]); ok {
+			es.witnessSGR(params)
+		} else if link, ok := parseHyperlink(s[i : i+n]); ok {
+			es.Link = link
 		}
+		i += n
 	}
 }
 
-func (es *EscapeState) witnessCode(s string) {
+// witnessSGR update the state with the parameters of a SGR sequence, like "1;31".
+func (es *EscapeState) witnessSGR(s string) {
 	if s == "" {
-		return
-	}
-	if s == "[" {
 		es.reset()
 		return
 	}
-	if len(s) < 2 {
-		return
-	}
-	if s[0] != '[' {
-		return
-	}
 
-	s = s[1:]
 	split := strings.Split(s, ";")
 
 	dequeue := func() {
@@ -76,47 +90,32 @@ func (es *EscapeState) witnessCode(s string) {
 			return nil
 		}
 
-		subCode := split[0]
-		dequeue()
-
-		switch subCode {
+		var c Color
+		switch split[0] {
 		case "2":
-			if len(split) < 3 {
-				return nil
-			}
-			r, err := strconv.Atoi(split[0])
-			dequeue()
-			if err != nil {
-				return nil
-			}
-			g, err := strconv.Atoi(split[0])
-			dequeue()
-			if err != nil {
+			if len(split) < 4 {
 				return nil
 			}
-			b, err := strconv.Atoi(split[0])
-			dequeue()
-			if err != nil {
-				return nil
-			}
-			return &ColorRGB{ground: ground, R: r, G: g, B: b}
-
+			c = parseColor(ground, split[:4])
+			split = split[4:]
 		case "5":
-			if len(split) < 1 {
+			if len(split) < 2 {
 				return nil
 			}
-			index, err := strconv.Atoi(split[0])
-			dequeue()
-			if err != nil {
-				return nil
-			}
-			return &Color256{ground: ground, Index: index}
-
+			c = parseColor(ground, split[:2])
+			split = split[2:]
 		}
-		return nil
+		return c
 	}
 
 	for len(split) > 0 {
+		if strings.Contains(split[0], ":") {
+			// sub-parameters, like "4:3" or "38:2::255:0:0"
+			es.witnessSubParams(strings.Split(split[0], ":"))
+			dequeue()
+			continue
+		}
+
 		code, err := strconv.Atoi(split[0])
 		if err != nil {
 			return
@@ -135,9 +134,11 @@ func (es *EscapeState) witnessCode(s string) {
 			es.Italic = true
 		case code == 4:
 			es.Underlined = true
+			es.UnderlineStyle = UnderlineSingle
 		case code == 5:
 			es.Blink = true
-		// case code == 6:
+		case code == 6:
+			es.FastBlink = true
 		case code == 7:
 			es.Reverse = true
 		case code == 8:
@@ -146,15 +147,20 @@ func (es *EscapeState) witnessCode(s string) {
 			es.CrossedOut = true
 
 		case code == 21:
-			es.Bold = false
+			// ECMA-48 double underline, not "bold off" as in some terminals
+			es.Underlined = true
+			es.UnderlineStyle = UnderlineDouble
 		case code == 22:
+			es.Bold = false
 			es.Dim = false
 		case code == 23:
 			es.Italic = false
 		case code == 24:
 			es.Underlined = false
+			es.UnderlineStyle = UnderlineSingle
 		case code == 25:
 			es.Blink = false
+			es.FastBlink = false
 		// case code == 26:
 		case code == 27:
 			es.Reverse = false
@@ -163,11 +169,15 @@ func (es *EscapeState) witnessCode(s string) {
 		case code == 29:
 			es.CrossedOut = false
 
-		case (code >= 30 && code <= 37) || code == 39 || (code >= 90 && code <= 97):
+		case (code >= 30 && code <= 37) || (code >= 90 && code <= 97):
 			es.FgColor = ColorIndex(code)
+		case code == 39:
+			es.FgColor = nil
 
-		case (code >= 40 && code <= 47) || code == 49 || (code >= 100 && code <= 107):
+		case (code >= 40 && code <= 47) || (code >= 100 && code <= 107):
 			es.BgColor = ColorIndex(code)
+		case code == 49:
+			es.BgColor = nil
 
 		case code == 38:
 			es.FgColor = color(code)
@@ -180,16 +190,139 @@ func (es *EscapeState) witnessCode(s string) {
 			if es.BgColor == nil {
 				return
 			}
+
+		case code == 51:
+			es.Framed = true
+		case code == 52:
+			es.Encircled = true
+		case code == 53:
+			es.Overlined = true
+		case code == 54:
+			es.Framed = false
+			es.Encircled = false
+		case code == 55:
+			es.Overlined = false
+
+		case code == 58:
+			es.UnderlineColor = color(code)
+			if es.UnderlineColor == nil {
+				return
+			}
+		case code == 59:
+			es.UnderlineColor = nil
+
+		case code == 73:
+			es.Superscript = true
+			es.Subscript = false
+		case code == 74:
+			es.Subscript = true
+			es.Superscript = false
+		case code == 75:
+			es.Superscript = false
+			es.Subscript = false
 		}
 	}
 }
 
+// witnessSubParams update the state with a SGR parameter with colon separated
+// sub-parameters, like "4:3" or "38:2::255:0:0".
+func (es *EscapeState) witnessSubParams(sub []string) {
+	code, err := strconv.Atoi(sub[0])
+	if err != nil {
+		return
+	}
+
+	switch code {
+	case 4:
+		style, err := strconv.Atoi(sub[1])
+		if err != nil {
+			return
+		}
+		switch {
+		case style == 0:
+			es.Underlined = false
+			es.UnderlineStyle = UnderlineSingle
+		case style >= 1 && style <= 5:
+			es.Underlined = true
+			es.UnderlineStyle = UnderlineStyle(style - 1)
+		}
+
+	case 38, 48, 58:
+		c := parseColor(code, sub[1:])
+		if c == nil {
+			return
+		}
+		switch code {
+		case 38:
+			es.FgColor = c
+		case 48:
+			es.BgColor = c
+		case 58:
+			es.UnderlineColor = c
+		}
+	}
+}
+
+// parseColor parse the parameters of an extended color, after the 38, 48 or 58
+// code: either "5" and an index, or "2" and the RGB components. With the colon
+// form, "2" can be followed by a color space id before the components, like
+// in "38:2::255:0:0".
+// Return nil if the parameters are invalid.
+func parseColor(ground int, params []string) Color {
+	if len(params) < 2 {
+		return nil
+	}
+
+	switch params[0] {
+	case "5":
+		index, err := strconv.Atoi(params[1])
+		if err != nil {
+			return nil
+		}
+		return &Color256{ground: ground, Index: index}
+
+	case "2":
+		rgb := params[1:]
+		if len(rgb) > 3 {
+			// skip the color space id
+			rgb = rgb[1:]
+		}
+		if len(rgb) < 3 {
+			return nil
+		}
+		var values [3]int
+		for i := range values {
+			v, err := strconv.Atoi(rgb[i])
+			if err != nil {
+				return nil
+			}
+			values[i] = v
+		}
+		return &ColorRGB{ground: ground, R: values[0], G: values[1], B: values[2]}
+	}
+
+	return nil
+}
+
+// reset clear the SGR attributes. As in a terminal, the hyperlink is left untouched.
 func (es *EscapeState) reset() {
-	*es = EscapeState{}
+	*es = EscapeState{Link: es.Link}
 }
 
-// FormatString return the escape codes to enable that formatting.
+// FormatString return the escape codes to enable that formatting, including
+// opening the hyperlink if any.
 func (es *EscapeState) FormatString() string {
+	codes := es.sgrCodes()
+
+	if len(codes) == 0 {
+		return es.Link.OpenString()
+	}
+
+	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";")) + es.Link.OpenString()
+}
+
+// sgrCodes return the SGR codes to enable that formatting from a zero state.
+func (es *EscapeState) sgrCodes() []string {
 	var codes []string
 
 	if es.Bold {
@@ -202,11 +335,14 @@ func (es *EscapeState) FormatString() string {
 		codes = append(codes, strconv.Itoa(3))
 	}
 	if es.Underlined {
-		codes = append(codes, strconv.Itoa(4))
+		codes = append(codes, underlineCode(es.UnderlineStyle))
 	}
 	if es.Blink {
 		codes = append(codes, strconv.Itoa(5))
 	}
+	if es.FastBlink {
+		codes = append(codes, strconv.Itoa(6))
+	}
 	if es.Reverse {
 		codes = append(codes, strconv.Itoa(7))
 	}
@@ -216,6 +352,21 @@ func (es *EscapeState) FormatString() string {
 	if es.CrossedOut {
 		codes = append(codes, strconv.Itoa(9))
 	}
+	if es.Framed {
+		codes = append(codes, strconv.Itoa(51))
+	}
+	if es.Encircled {
+		codes = append(codes, strconv.Itoa(52))
+	}
+	if es.Overlined {
+		codes = append(codes, strconv.Itoa(53))
+	}
+	if es.Superscript {
+		codes = append(codes, strconv.Itoa(73))
+	}
+	if es.Subscript {
+		codes = append(codes, strconv.Itoa(74))
+	}
 
 	if es.FgColor != nil {
 		codes = append(codes, es.FgColor.Codes()...)
@@ -223,34 +374,158 @@ func (es *EscapeState) FormatString() string {
 	if es.BgColor != nil {
 		codes = append(codes, es.BgColor.Codes()...)
 	}
+	if es.UnderlineColor != nil {
+		codes = append(codes, es.UnderlineColor.Codes()...)
+	}
 
-	if len(codes) == 0 {
-		return ""
+	return codes
+}
+
+// underlineCode return the SGR code enabling an underline of the given style.
+func underlineCode(style UnderlineStyle) string {
+	if style == UnderlineSingle {
+		return strconv.Itoa(4)
+	}
+	return fmt.Sprintf("4:%d", style+1)
+}
+
+// TransitionString return the shortest escape codes to go from this state to
+// the given one, either with targeted codes, like "22" to disable bold, or
+// with a global reset followed by the codes of the target state. The
+// hyperlink is opened or closed as needed.
+func (es *EscapeState) TransitionString(to *EscapeState) string {
+	var result string
+
+	targeted := es.transitionCodes(to)
+	if len(targeted) > 0 {
+		result = fmt.Sprintf("\x1b[%sm", strings.Join(targeted, ";"))
+		reset := fmt.Sprintf("\x1b[%sm", strings.Join(append([]string{"0"}, to.sgrCodes()...), ";"))
+		if len(reset) < len(result) {
+			result = reset
+		}
+	}
+
+	if es.Link != to.Link {
+		if to.Link.IsZero() {
+			result += es.Link.CloseString()
+		} else {
+			result += to.Link.OpenString()
+		}
 	}
 
-	return fmt.Sprintf("\x1b[%sm", strings.Join(codes, ";"))
+	return result
+}
+
+// transitionCodes return the targeted SGR codes to go from this state to the
+// given one, in the same order as sgrCodes.
+func (es *EscapeState) transitionCodes(to *EscapeState) []string {
+	var codes []string
+
+	flag := func(from, to bool, on, off int) {
+		switch {
+		case to && !from:
+			codes = append(codes, strconv.Itoa(on))
+		case from && !to:
+			codes = append(codes, strconv.Itoa(off))
+		}
+	}
+
+	// attributes disabled by the same code
+	pair := func(fromA, fromB, toA, toB bool, onA, onB, off int) {
+		if (fromA && !toA) || (fromB && !toB) {
+			codes = append(codes, strconv.Itoa(off))
+			fromA, fromB = false, false
+		}
+		if toA && !fromA {
+			codes = append(codes, strconv.Itoa(onA))
+		}
+		if toB && !fromB {
+			codes = append(codes, strconv.Itoa(onB))
+		}
+	}
+
+	color := func(from, to Color, off int) {
+		switch {
+		case colorEqual(from, to):
+		case to == nil:
+			codes = append(codes, strconv.Itoa(off))
+		default:
+			codes = append(codes, to.Codes()...)
+		}
+	}
+
+	pair(es.Bold, es.Dim, to.Bold, to.Dim, 1, 2, 22)
+	flag(es.Italic, to.Italic, 3, 23)
+	switch {
+	case to.Underlined && (!es.Underlined || es.UnderlineStyle != to.UnderlineStyle):
+		codes = append(codes, underlineCode(to.UnderlineStyle))
+	case es.Underlined && !to.Underlined:
+		codes = append(codes, strconv.Itoa(24))
+	}
+	pair(es.Blink, es.FastBlink, to.Blink, to.FastBlink, 5, 6, 25)
+	flag(es.Reverse, to.Reverse, 7, 27)
+	flag(es.Hidden, to.Hidden, 8, 28)
+	flag(es.CrossedOut, to.CrossedOut, 9, 29)
+	pair(es.Framed, es.Encircled, to.Framed, to.Encircled, 51, 52, 54)
+	flag(es.Overlined, to.Overlined, 53, 55)
+	if es.Superscript != to.Superscript || es.Subscript != to.Subscript {
+		switch {
+		case to.Superscript:
+			codes = append(codes, strconv.Itoa(73))
+		case to.Subscript:
+			codes = append(codes, strconv.Itoa(74))
+		default:
+			codes = append(codes, strconv.Itoa(75))
+		}
+	}
+
+	color(es.FgColor, to.FgColor, 39)
+	color(es.BgColor, to.BgColor, 49)
+	color(es.UnderlineColor, to.UnderlineColor, 59)
+
+	return codes
+}
+
+// colorEqual return true if both colors are the same, or both nil.
+func colorEqual(a, b Color) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return strings.Join(a.Codes(), ";") == strings.Join(b.Codes(), ";")
 }
 
 // ResetString return either the global reset code or nothing, depending on if
-// this state has something to reset or not.
+// this state has something to reset or not. An active hyperlink is closed as well.
 func (es *EscapeState) ResetString() string {
-	if es.IsZero() {
-		return ""
+	if es.sgrIsZero() {
+		return es.Link.CloseString()
 	}
-	return "\x1b[0m"
+	return "\x1b[0m" + es.Link.CloseString()
 }
 
 func (es *EscapeState) IsZero() bool {
+	return es.sgrIsZero() && es.Link.IsZero()
+}
+
+// sgrIsZero return true if no SGR attribute is set, ignoring the hyperlink.
+func (es *EscapeState) sgrIsZero() bool {
 	return !es.Bold &&
 		!es.Dim &&
 		!es.Italic &&
 		!es.Underlined &&
 		!es.Blink &&
+		!es.FastBlink &&
 		!es.Reverse &&
 		!es.Hidden &&
 		!es.CrossedOut &&
+		!es.Overlined &&
+		!es.Framed &&
+		!es.Encircled &&
+		!es.Superscript &&
+		!es.Subscript &&
 		es.FgColor == nil &&
-		es.BgColor == nil
+		es.BgColor == nil &&
+		es.UnderlineColor == nil
 }
 
 type ColorIndex int
@@ -28,6 +28,63 @@ func TestEscapeState(t *testing.T) {
 			"\x1b[48m",
 			"",
 		},
+		{
+			// non-SGR sequences are ignored
+			"\x1b[2K\x1b[31mfoo\x1b]0;title\x07\x1b[1;1H",
+			"\x1b[31m",
+		},
+		{
+			"\x1b[1mfoo\x1b[m",
+			"",
+		},
+		{
+			"\x1b]8;;https://example.com\x1b\\foo",
+			"\x1b]8;;https://example.com\x1b\\",
+		},
+		{
+			// a SGR reset doesn't close the hyperlink
+			"\x1b[31m\x1b]8;;https://example.com\x07foo\x1b[0m",
+			"\x1b]8;;https://example.com\x1b\\",
+		},
+		{
+			"\x1b[31m\x1b]8;;https://example.com\x1b\\foo\x1b]8;;\x1b\\",
+			"\x1b[31m",
+		},
+		{
+			"\x1b[6;51;52;53;73m",
+			"\x1b[6;51;52;53;73m",
+		},
+		{
+			// 21 is a double underline, 22 reset both bold and dim
+			"\x1b[1;2;21mfoo\x1b[22m",
+			"\x1b[4:2m",
+		},
+		{
+			"\x1b[4:3mfoo\x1b[6;53;54;74m\x1b[25;55m",
+			"\x1b[4:3;74m",
+		},
+		{
+			"\x1b[4:5m\x1b[4:0mfoo\x1b[4m",
+			"\x1b[4m",
+		},
+		{
+			"\x1b[73;74;58;5;100m",
+			"\x1b[74;58;5;100m",
+		},
+		{
+			"\x1b[58;2;1;2;3m\x1b[59;31m",
+			"\x1b[31m",
+		},
+		{
+			// colon forms, with or without the color space id
+			"\x1b[38:2::255:0:10;48:5:12;58:2:1:2:3m",
+			"\x1b[38;2;255;0;10;48;5;12;58;2;1;2;3m",
+		},
+		{
+			// broken colon forms are ignored
+			"\x1b[38:2:1:2;1;48:7:1m",
+			"\x1b[1m",
+		},
 	}
 
 	for i, tc := range cases {
@@ -44,3 +101,49 @@ func TestEscapeState(t *testing.T) {
 		})
 	}
 }
+
+func TestEscapeStateTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		output   string
+	}{
+		{"", "", ""},
+		{"\x1b[1;31m", "\x1b[1;31m", ""},
+		{"", "\x1b[1;31m", "\x1b[1;31m"},
+		{"\x1b[1;31m", "", "\x1b[0m"},
+		// targeted resets
+		{"\x1b[1;3;31;44m", "\x1b[1;31;44m", "\x1b[23m"},
+		{"\x1b[1;31;44m", "\x1b[1;44m", "\x1b[39m"},
+		{"\x1b[1;2;31m", "\x1b[2;31m", "\x1b[22;2m"},
+		{"\x1b[4;31m", "\x1b[4:3;32m", "\x1b[4:3;32m"},
+		{"\x1b[4:3;53;73m", "\x1b[53;74m", "\x1b[24;74m"},
+		{"\x1b[5;51;58;5;100;31m", "\x1b[6;51;52;31m", "\x1b[25;6;52;59m"},
+		{"\x1b[38;2;1;2;3;48;5;1m", "\x1b[38;2;1;2;3m", "\x1b[49m"},
+		// a global reset is shorter
+		{"\x1b[1;3;4;31;44m", "\x1b[7m", "\x1b[0;7m"},
+		// hyperlinks
+		{"\x1b]8;;http://a\x1b\\", "\x1b[1m", "\x1b[1m\x1b]8;;\x1b\\"},
+		{"\x1b]8;;http://a\x1b\\", "\x1b]8;;http://b\x1b\\", "\x1b]8;;http://b\x1b\\"},
+		// a SGR reset doesn't close the hyperlink
+		{"\x1b[1m\x1b]8;;http://a\x1b\\", "\x1b]8;;http://a\x1b\\", "\x1b[0m"},
+	}
+
+	for i, tc := range cases {
+		var from, to EscapeState
+		from.Witness(tc.from)
+		to.Witness(tc.to)
+
+		result := from.TransitionString(&to)
+		if result != tc.output {
+			t.Fatalf("Case %d From %q To %q\nExpected Output: %q\nActual Output: %q",
+				i, tc.from, tc.to, tc.output, result)
+		}
+
+		// applying the transition gives the target state
+		from.Witness(result)
+		if from.FormatString() != to.FormatString() {
+			t.Fatalf("Case %d From %q To %q\nExpected State: %q\nActual State: %q",
+				i, tc.from, tc.to, to.FormatString(), from.FormatString())
+		}
+	}
+}
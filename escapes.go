@@ -8,7 +8,7 @@ import (
 // EscapeItem hold the description of terminal escapes in a line.
 // 'item' is the actual escape command
 // 'pos' is the index in the rune array where the 'item' shall be inserted back.
-// For example, the escape item in "F\x1b33mox" is {"\x1b33m", 1}.
+// For example, the escape item in "F\x1b[33mox" is {"\x1b[33m", 1}.
 type EscapeItem struct {
 	Item string
 	Pos  int
@@ -19,32 +19,25 @@ type EscapeItem struct {
 // can be inserted back into the new line at rune index 'item.pos' to recover the
 // original line.
 //
+// All the ECMA-48 sequences are recognized: CSI, OSC, DCS/SOS/PM/APC strings,
+// two-byte escape sequences and C1 controls.
+//
 // Required: The line shall not contain "\n"
 func ExtractTermEscapes(line string) (string, []EscapeItem) {
 	var termEscapes []EscapeItem
 	var line1 strings.Builder
 
 	pos := 0
-	item := ""
-	occupiedRuneCount := 0
-	inEscape := false
-	for i, r := range []rune(line) {
-		if r == '\x1b' {
-			pos = i
-			item = string(r)
-			inEscape = true
-			continue
-		}
-		if inEscape {
-			item += string(r)
-			if r == 'm' {
-				termEscapes = append(termEscapes, EscapeItem{item, pos - occupiedRuneCount})
-				occupiedRuneCount += utf8.RuneCountInString(item)
-				inEscape = false
-			}
+	for i := 0; i < len(line); {
+		if n := escapeLen(line[i:]); n > 0 {
+			termEscapes = append(termEscapes, EscapeItem{line[i : i+n], pos})
+			i += n
 			continue
 		}
+		r, size := utf8.DecodeRuneInString(line[i:])
 		line1.WriteRune(r)
+		pos++
+		i += size
 	}
 
 	return line1.String(), termEscapes
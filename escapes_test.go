@@ -52,6 +52,17 @@ func TestExtractApplyTermEscapes(t *testing.T) {
 				{"\x1b[0m", 10},
 				{"\x1b[1m", 19}, {"\x1b[31m", 19}},
 		},
+
+		{
+			"Non-SGR escapes",
+			"\x1b[2KThis \x1b]0;title\x07is\x1b7 an\x1b[?25l example.",
+			"This is an example.",
+			[]EscapeItem{
+				{"\x1b[2K", 0},
+				{"\x1b]0;title\x07", 5},
+				{"\x1b7", 7},
+				{"\x1b[?25l", 10}},
+		},
 	}
 
 	for _, tc := range cases {
@@ -5,7 +5,9 @@ import (
 	"strings"
 )
 
-func ExampleWrapWithPadIndent() {
+// wrapExample return the output of the Readme example, also used to
+// generate img/example.svg (see TestToSVGExample).
+func wrapExample() string {
 	input := "The \x1b[1mLorem ipsum\x1b[0m text is typically composed of " +
 		"pseudo-Latin words. It is commonly used as \x1b[3mplaceholder\x1b[0m" +
 		" text to examine or demonstrate the \x1b[9mvisual effects\x1b[0m of " +
@@ -15,11 +17,16 @@ func ExampleWrapWithPadIndent() {
 	output, n := WrapWithPadIndent(input, 60,
 		"\x1b[34m<-indent-> \x1b[0m", "\x1b[33m<-pad-> \x1b[0m")
 
-	fmt.Println()
-	fmt.Printf("output has %d lines\n\n", n)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("output has %d lines\n\n", n))
+	sb.WriteString("|" + strings.Repeat("-", 58) + "|\n")
+	sb.WriteString(output + "\n")
+	sb.WriteString("|" + strings.Repeat("-", 58) + "|\n")
+	return sb.String()
+}
 
-	fmt.Println("|" + strings.Repeat("-", 58) + "|")
-	fmt.Println(output)
-	fmt.Println("|" + strings.Repeat("-", 58) + "|")
+func ExampleWrapWithPadIndent() {
+	fmt.Println()
+	fmt.Print(wrapExample())
 	fmt.Println()
 }
@@ -0,0 +1,153 @@
+package text
+
+import "strings"
+
+// Fitting select how Wrap() distribute the words between the lines.
+type Fitting int
+
+const (
+	// FitGreedy fill each line with as many words as possible before going
+	// to the next one.
+	FitGreedy Fitting = iota
+	// FitOptimal consider the paragraph as a whole and place the line breaks
+	// so that the lines have a similar length, giving a less ragged right edge
+	// (minimum raggedness, in the spirit of Knuth–Plass).
+	FitOptimal
+)
+
+// noFit is the cost of an impossible line
+const noFit = -1
+
+// hyphenPenalty is the additional cost of a line ending with a hyphen, so that
+// breaking words is only done when it really improves the layout.
+const hyphenPenalty = 10
+
+// fitOptimal distribute the chunks in lines so that the sum of the squared
+// empty space at the end of each line, except the last one, is minimal.
+// Chunks longer than lineWidth are split beforehand.
+//
+// Spaces at the end of a line, or at the start of any line but the first one
+// are not counted, as Wrap() trims them.
+//
+// With hyphenation, a line ending with a soft hyphen gets a visible hyphen
+// and a small penalty.
+//
+// Return nil if no layout fits in lineWidth, like with a character wider than
+// the line.
+func fitOptimal(chunks []string, lineWidth int, hyphenation bool) [][]string {
+	// words too long for a full line are split to fill whole lines
+	var items []string
+	for _, chunk := range chunks {
+		for {
+			width := Len(chunk)
+			hyphen := 0
+			if hyphenation && endsWithSoftHyphen(chunk) {
+				hyphen = 1
+			}
+			if width+hyphen <= lineWidth {
+				break
+			}
+			splitWidth := lineWidth
+			if width <= lineWidth && lineWidth > 1 {
+				// only the hyphen doesn't fit, the end of the word goes
+				// with it on the next line
+				splitWidth = lineWidth - hyphen
+			}
+			var left string
+			left, chunk = splitWord(chunk, splitWidth)
+			items = append(items, left)
+		}
+		if chunk != "" {
+			items = append(items, chunk)
+		}
+	}
+
+	n := len(items)
+	widths := make([]int, n)
+	spaces := make([]bool, n)
+	hyphens := make([]bool, n)
+	for i, item := range items {
+		widths[i] = Len(item)
+		spaces[i] = strings.Trim(item, " ") == ""
+		hyphens[i] = hyphenation && endsWithSoftHyphen(item)
+	}
+
+	// cost[j] is the minimal cost to lay out items[:j], with the last line
+	// starting at items[start[j]]
+	cost := make([]int, n+1)
+	start := make([]int, n+1)
+
+	for j := 1; j <= n; j++ {
+		cost[j] = noFit
+
+		// the trailing spaces are not visible
+		trailing := 0
+		for k := j - 1; k >= 0 && spaces[k]; k-- {
+			trailing += widths[k]
+		}
+
+		sum := 0
+		leading := 0
+		for i := j - 1; i >= 0; i-- {
+			sum += widths[i]
+			if spaces[i] {
+				leading += widths[i]
+			} else {
+				leading = 0
+			}
+
+			content := sum - trailing
+			if i > 0 {
+				content -= leading
+			}
+			hyphen := j < n && hyphens[j-1]
+			if hyphen {
+				content++
+			}
+			if content > lineWidth {
+				break
+			}
+			if cost[i] == noFit {
+				continue
+			}
+
+			var lineCost int
+			switch {
+			case content <= 0 && i > 0:
+				// a line with only spaces would render as an empty line
+				lineCost = lineWidth*lineWidth + 1
+			case j == n:
+				// the last line can be as short as needed
+				lineCost = 0
+			default:
+				lineCost = (lineWidth - content) * (lineWidth - content)
+			}
+			if hyphen {
+				lineCost += hyphenPenalty
+			}
+
+			if cost[j] == noFit || cost[i]+lineCost < cost[j] {
+				cost[j] = cost[i] + lineCost
+				start[j] = i
+			}
+		}
+	}
+
+	if cost[n] == noFit {
+		return nil
+	}
+
+	// walk back the chosen breaks
+	var breaks []int
+	for j := n; j > 0; j = start[j] {
+		breaks = append(breaks, start[j])
+	}
+
+	lines := make([][]string, len(breaks))
+	end := n
+	for k, b := range breaks {
+		lines[len(breaks)-1-k] = items[b:end]
+		end = b
+	}
+	return lines
+}
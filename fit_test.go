@@ -0,0 +1,93 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitOptimal(t *testing.T) {
+	cases := []struct {
+		chunks []string
+		width  int
+		lines  []string
+	}{
+		{
+			[]string{"aaa", " ", "bb", " ", "cc", " ", "ddddd"},
+			6,
+			[]string{"aaa ", "bb cc ", "ddddd"},
+		},
+		// the last line doesn't count
+		{
+			[]string{"aaa", " ", "bb", " ", "c"},
+			6,
+			[]string{"aaa bb ", "c"},
+		},
+		// too long words are split
+		{
+			[]string{"foo", " ", "barbazqux"},
+			4,
+			[]string{"foo ", "barb", "azqu", "x"},
+		},
+		// wide characters
+		{
+			[]string{"一", "只", "敏", "捷", "的", " ", "fox"},
+			6,
+			[]string{"一只敏", "捷的 ", "fox"},
+		},
+		// no layout fits, with wide characters wider than the line
+		{
+			[]string{"一只", " ", "ab"},
+			1,
+			nil,
+		},
+		// leading spaces are kept on the first line only
+		{
+			[]string{"  ", "foo", " ", "bar"},
+			5,
+			[]string{"  foo ", "bar"},
+		},
+		{
+			nil,
+			5,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		var lines []string
+		for _, l := range fitOptimal(tc.chunks, tc.width, false) {
+			lines = append(lines, strings.Join(l, ""))
+		}
+		assert.Equal(t, tc.lines, lines)
+	}
+}
+
+func TestWrapWideCharNarrowWidth(t *testing.T) {
+	for _, fitting := range []Fitting{FitGreedy, FitOptimal} {
+		output, lines := Wrap("a 一只", 1, WrapFitting(fitting))
+		assert.Equal(t, "a\n一\n只", output)
+		assert.Equal(t, 3, lines)
+	}
+}
+
+func TestWrapFitOptimalNoFit(t *testing.T) {
+	// no layout fits in the width, the greedy fitting is used instead
+	text := "The 一只 quick 敏捷的 brown fox"
+	greedy, greedyLines := Wrap(text, 1)
+	output, lines := Wrap(text, 1, WrapFitting(FitOptimal))
+	assert.Equal(t, greedy, output)
+	assert.Equal(t, greedyLines, lines)
+}
+
+func BenchmarkFitOptimal(b *testing.B) {
+	chunks := segmentLine("The Lorem ipsum text is typically composed of pseudo-Latin words. It is commonly used as placeholder text to examine or demonstrate the visual effects of various graphic design.")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		fitOptimal(chunks, 30, false)
+	}
+}
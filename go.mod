@@ -4,5 +4,6 @@ go 1.11
 
 require (
 	github.com/mattn/go-runewidth v0.0.13
+	github.com/rivo/uniseg v0.2.0
 	github.com/stretchr/testify v1.7.0
 )
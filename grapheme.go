@@ -0,0 +1,178 @@
+package text
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	// variationSelector16 request the emoji presentation of the preceding character.
+	variationSelector16 = '\ufe0f'
+
+	regionalIndicatorA = '\U0001f1e6'
+	regionalIndicatorZ = '\U0001f1ff'
+)
+
+// graphemeWidth return the width in a terminal of an extended grapheme cluster.
+//
+// The width of a cluster is the width of its first non zero-width rune, with
+// a few exceptions to match how terminals render emojis:
+// - a character followed by VS16 is shown with its emoji presentation, two cells wide
+// - a flag (pair of regional indicators) is two cells wide
+// - format characters (word joiner, soft hyphen ...) are zero-width
+func graphemeWidth(cluster string) int {
+	width := 0
+	emoji := false
+	regional := 0
+
+	for _, r := range cluster {
+		switch {
+		case r == variationSelector16:
+			emoji = true
+			continue
+		case r >= regionalIndicatorA && r <= regionalIndicatorZ:
+			regional++
+		}
+		if width == 0 && !unicode.Is(unicode.Cf, r) {
+			width = runewidth.RuneWidth(r)
+		}
+	}
+
+	if width > 0 && (emoji || regional >= 2) {
+		return 2
+	}
+	return width
+}
+
+// walkGraphemes split s into terminal escape sequences and extended grapheme
+// clusters, and call fn for each of them in order with their width in a
+// terminal. Escape sequences are always zero-width.
+// The iteration stops as soon as fn returns false.
+func walkGraphemes(s string, fn func(str string, escape bool, width int) bool) {
+	start := 0
+
+	walkText := func(end int) bool {
+		if start == end {
+			return true
+		}
+		// uniseg v0.2.0 loses its state after a control character, and can
+		// then break inside the following cluster. As a control is always a
+		// cluster on its own (or CR LF), the text is split on them and only
+		// the parts in between are segmented.
+		segStart := start
+		segment := func(segEnd int) bool {
+			if segStart == segEnd {
+				return true
+			}
+			g := uniseg.NewGraphemes(s[segStart:segEnd])
+			for g.Next() {
+				if !fn(g.Str(), false, graphemeWidth(g.Str())) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for i := start; i < end; {
+			r, size := utf8.DecodeRuneInString(s[i:end])
+			if !isGraphemeControl(r) {
+				i += size
+				continue
+			}
+			if !segment(i) {
+				return false
+			}
+			if r == '\r' && i+1 < end && s[i+1] == '\n' {
+				size = 2
+			}
+			if !fn(s[i:i+size], false, graphemeWidth(s[i:i+size])) {
+				return false
+			}
+			i += size
+			segStart = i
+		}
+		return segment(end)
+	}
+
+	for i := 0; i < len(s); {
+		n := escapeLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		if !walkText(i) {
+			return
+		}
+		if !fn(s[i:i+n], true, 0) {
+			return
+		}
+		i += n
+		start = i
+	}
+
+	walkText(len(s))
+}
+
+// isGraphemeControl return true if r is of the Control, CR or LF grapheme
+// cluster break property (UAX #29), that is always a cluster on its own, apart
+// from CR LF.
+func isGraphemeControl(r rune) bool {
+	switch {
+	case r == '\u200c' || r == '\u200d':
+		// ZWNJ and ZWJ extend the cluster
+		return false
+	case r >= '\U000e0020' && r <= '\U000e007f':
+		// tags, used in emoji tag sequences
+		return false
+	case unicode.In(r, unicode.Cc, unicode.Zl, unicode.Zp):
+		return true
+	case unicode.Is(unicode.Cf, r):
+		return !isPrependedConcatenationMark(r)
+	}
+	return false
+}
+
+// isPrependedConcatenationMark return true for the format characters of the
+// Prepend grapheme cluster break property, that join with the next character.
+func isPrependedConcatenationMark(r rune) bool {
+	switch {
+	case r >= '\u0600' && r <= '\u0605':
+		return true
+	case r == '\u06dd' || r == '\u070f' || r == '\u08e2' || r == '\U000110bd' || r == '\U000110cd':
+		return true
+	}
+	return false
+}
+
+// maxGraphemeWidth return the width of the widest grapheme cluster of s, that
+// is the minimal width to wrap it without overflowing.
+func maxGraphemeWidth(s string) int {
+	max := 0
+	walkGraphemes(s, func(_ string, _ bool, width int) bool {
+		if width > max {
+			max = width
+		}
+		return true
+	})
+	return max
+}
+
+// truncateWidth return the longest prefix of s, cut on a grapheme cluster
+// boundary, that fits in the given width. Escape sequences are kept if they
+// come before the cut.
+func truncateWidth(s string, width int) string {
+	end := 0
+	total := 0
+	walkGraphemes(s, func(str string, escape bool, w int) bool {
+		if total+w > width {
+			return false
+		}
+		total += w
+		end += len(str)
+		return true
+	})
+	return s[:end]
+}
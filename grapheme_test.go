@@ -0,0 +1,98 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphemeWidth(t *testing.T) {
+	cases := []struct {
+		input string
+		width int
+	}{
+		{"a", 1},
+		{"敏", 2},
+		{"e\u0301", 1},
+		{"\u2714", 1},
+		{"\u2714\ufe0f", 2},
+		{"\U0001f1eb\U0001f1f7", 2},
+		{"\U0001f44d\U0001f3fd", 2},
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 2},
+		{"\u200b", 0},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.width, graphemeWidth(tc.input), tc.input)
+	}
+}
+
+func TestWalkGraphemes(t *testing.T) {
+	var strs []string
+	var widths []int
+	walkGraphemes("e\u0301\x1b[31m\U0001f1eb\U0001f1f7\x1b[0m敏", func(str string, escape bool, width int) bool {
+		strs = append(strs, str)
+		widths = append(widths, width)
+		return true
+	})
+
+	assert.Equal(t, []string{"e\u0301", "\x1b[31m", "\U0001f1eb\U0001f1f7", "\x1b[0m", "敏"}, strs)
+	assert.Equal(t, []int{1, 0, 2, 0, 2}, widths)
+}
+
+func TestWalkGraphemesAfterControl(t *testing.T) {
+	cases := []struct {
+		input string
+		strs  []string
+	}{
+		{
+			"\n\U0001f468\u200d\U0001f469\u200d\U0001f467",
+			[]string{"\n", "\U0001f468\u200d\U0001f469\u200d\U0001f467"},
+		},
+		{
+			"a\u200b\U0001f468\u200d\U0001f469\u200d\U0001f467",
+			[]string{"a", "\u200b", "\U0001f468\u200d\U0001f469\u200d\U0001f467"},
+		},
+		{
+			"\u200b\U0001f1eb\U0001f1f7\U0001f1eb\U0001f1f7",
+			[]string{"\u200b", "\U0001f1eb\U0001f1f7", "\U0001f1eb\U0001f1f7"},
+		},
+		{
+			"\u200b\r\nfoo",
+			[]string{"\u200b", "\r\n", "f", "o", "o"},
+		},
+		{
+			"\u00ade\u0301\x1b[31m\u0007e\u0301",
+			[]string{"\u00ad", "e\u0301", "\x1b[31m", "\u0007", "e\u0301"},
+		},
+	}
+
+	for _, tc := range cases {
+		var strs []string
+		walkGraphemes(tc.input, func(str string, _ bool, _ int) bool {
+			strs = append(strs, str)
+			return true
+		})
+		assert.Equal(t, tc.strs, strs, tc.input)
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	cases := []struct {
+		input  string
+		width  int
+		output string
+	}{
+		{"foobar", 3, "foo"},
+		{"foo", 5, "foo"},
+		{"敏捷的", 3, "敏"},
+		{"\x1b[31mfoo\x1b[0mbar", 3, "\x1b[31mfoo\x1b[0m"},
+		{"ae\u0301\u0301b", 2, "ae\u0301\u0301"},
+		{"a\U0001f468\u200d\U0001f469\u200d\U0001f467b", 2, "a"},
+		{"foo", 0, ""},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.output, truncateWidth(tc.input, tc.width))
+	}
+}
@@ -0,0 +1,350 @@
+package text
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+type htmlOpts struct {
+	classes     bool
+	classPrefix string
+	palette     [16]string
+	tabWidth    int
+}
+
+// HTMLOption is a functional option for the ToHTML() function
+type HTMLOption func(opts *htmlOpts)
+
+// HTMLClasses make ToHTML() use CSS classes with the given prefix for the
+// attributes and the 16 basic colors, instead of inline styles, like
+// "term-bold" or "term-fg-1". The 256 and RGB colors still use inline styles.
+// HTMLStylesheet() give the matching CSS.
+func HTMLClasses(prefix string) HTMLOption {
+	return func(opts *htmlOpts) {
+		opts.classes = true
+		opts.classPrefix = prefix
+	}
+}
+
+// HTMLPalette configure the CSS colors used for the 16 basic colors, the 8
+// normal ones followed by the 8 bright ones. The default is the xterm palette.
+func HTMLPalette(palette [16]string) HTMLOption {
+	return func(opts *htmlOpts) {
+		opts.palette = palette
+	}
+}
+
+// HTMLTabWidth configure the distance between two tab stops. The default is
+// TabWidth.
+func HTMLTabWidth(tabWidth int) HTMLOption {
+	return func(opts *htmlOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+func allHTMLOpts(opts []HTMLOption) *htmlOpts {
+	htmlOpts := &htmlOpts{tabWidth: TabWidth}
+	for i, c := range basicColors {
+		htmlOpts.palette[i] = rgbCSS(c[0], c[1], c[2])
+	}
+	for _, opt := range opts {
+		opt(htmlOpts)
+	}
+	return htmlOpts
+}
+
+// ToHTML convert a text styled with SGR sequences into HTML, with a span for
+// each run of text with the same style, and a link for each OSC 8 hyperlink
+// with a safe URL scheme (http, https, mailto or ftp).
+// The text is HTML escaped, the tabs are expanded (see HTMLTabWidth), and the line
+// breaks are kept, each line being self-contained: the result is meant to be
+// displayed in a <pre> element, or with the "white-space: pre" CSS property.
+// Other escape sequences and control characters are removed.
+//
+// With reverse video, the default colors are taken as the basic colors 7 and 0.
+func ToHTML(s string, opts ...HTMLOption) string {
+	htmlOpts := allHTMLOpts(opts)
+
+	var out, run strings.Builder
+	var state, runState EscapeState
+
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		htmlOpts.writeRun(&out, &runState, run.String())
+		run.Reset()
+	}
+
+	walkGraphemes(expandTabs(s, 0, htmlOpts.tabWidth), func(str string, escape bool, _ int) bool {
+		switch {
+		case escape:
+			state.Witness(str)
+			return true
+		case strings.HasSuffix(str, "\n"):
+			// "\n" or "\r\n"
+			flush()
+			out.WriteString("\n")
+			return true
+		case len(str) == 1 && (str[0] < 0x20 || str[0] == 0x7f):
+			return true
+		}
+
+		if state.FormatString() != runState.FormatString() {
+			flush()
+			runState = state
+		}
+		run.WriteString(html.EscapeString(str))
+		return true
+	})
+	flush()
+
+	return out.String()
+}
+
+// HTMLStylesheet return the CSS rules for the classes used by ToHTML() with
+// the HTMLClasses() option.
+func HTMLStylesheet(opts ...HTMLOption) string {
+	htmlOpts := allHTMLOpts(opts)
+	p := htmlOpts.classPrefix
+
+	var sb strings.Builder
+	rule := func(class string, style string) {
+		sb.WriteString(fmt.Sprintf(".%s%s { %s; }\n", p, class, style))
+	}
+
+	rule("bold", "font-weight: bold")
+	rule("dim", "opacity: 0.5")
+	rule("italic", "font-style: italic")
+	rule("underline", "text-decoration-line: underline")
+	rule("underline-double", "text-decoration-style: double")
+	rule("underline-curly", "text-decoration-style: wavy")
+	rule("underline-dotted", "text-decoration-style: dotted")
+	rule("underline-dashed", "text-decoration-style: dashed")
+	rule("overline", "text-decoration-line: overline")
+	rule("crossed-out", "text-decoration-line: line-through")
+	// combined decorations
+	rule("underline."+p+"overline", "text-decoration-line: underline overline")
+	rule("underline."+p+"crossed-out", "text-decoration-line: underline line-through")
+	rule("overline."+p+"crossed-out", "text-decoration-line: overline line-through")
+	rule("underline."+p+"overline."+p+"crossed-out", "text-decoration-line: underline overline line-through")
+	rule("blink", "animation: "+p+"blink 1s step-end infinite")
+	rule("hidden", "visibility: hidden")
+	rule("framed", "border: 1px solid")
+	rule("encircled", "border: 1px solid; border-radius: 50%")
+	rule("superscript", "vertical-align: super; font-size: smaller")
+	rule("subscript", "vertical-align: sub; font-size: smaller")
+	for i, c := range htmlOpts.palette {
+		rule(fmt.Sprintf("fg-%d", i), "color: "+c)
+		rule(fmt.Sprintf("bg-%d", i), "background-color: "+c)
+	}
+	sb.WriteString(fmt.Sprintf("@keyframes %sblink { 50%% { visibility: hidden; } }\n", p))
+
+	return sb.String()
+}
+
+// writeRun write a run of HTML escaped text with the given style.
+func (opts *htmlOpts) writeRun(out *strings.Builder, es *EscapeState, text string) {
+	var href string
+	if !es.Link.IsZero() && safeURL(es.Link.URL) {
+		href = es.Link.URL
+	}
+
+	if href != "" {
+		out.WriteString(`<a href="` + html.EscapeString(href) + `">`)
+	}
+
+	classes, styles := opts.style(es)
+	var attrs []string
+	if len(classes) > 0 {
+		attrs = append(attrs, `class="`+strings.Join(classes, " ")+`"`)
+	}
+	if len(styles) > 0 {
+		attrs = append(attrs, `style="`+html.EscapeString(strings.Join(styles, "; "))+`"`)
+	}
+
+	if len(attrs) > 0 {
+		out.WriteString("<span " + strings.Join(attrs, " ") + ">")
+		out.WriteString(text)
+		out.WriteString("</span>")
+	} else {
+		out.WriteString(text)
+	}
+
+	if href != "" {
+		out.WriteString("</a>")
+	}
+}
+
+// style return the CSS classes and inline styles of an escape state.
+func (opts *htmlOpts) style(es *EscapeState) (classes []string, styles []string) {
+	attribute := func(class string, style string) {
+		if opts.classes {
+			classes = append(classes, opts.classPrefix+class)
+		} else if style != "" {
+			styles = append(styles, style)
+		}
+	}
+
+	if es.Bold {
+		attribute("bold", "font-weight: bold")
+	}
+	if es.Dim {
+		attribute("dim", "opacity: 0.5")
+	}
+	if es.Italic {
+		attribute("italic", "font-style: italic")
+	}
+
+	// the decorations are combined in a single property
+	var decorations []string
+	decoration := func(class string, value string) {
+		if opts.classes {
+			classes = append(classes, opts.classPrefix+class)
+		} else {
+			decorations = append(decorations, value)
+		}
+	}
+	if es.Underlined {
+		decoration("underline", "underline")
+	}
+	if es.Overlined {
+		decoration("overline", "overline")
+	}
+	if es.CrossedOut {
+		decoration("crossed-out", "line-through")
+	}
+	if len(decorations) > 0 {
+		styles = append(styles, "text-decoration-line: "+strings.Join(decorations, " "))
+	}
+	if es.Underlined {
+		switch es.UnderlineStyle {
+		case UnderlineDouble:
+			attribute("underline-double", "text-decoration-style: double")
+		case UnderlineCurly:
+			attribute("underline-curly", "text-decoration-style: wavy")
+		case UnderlineDotted:
+			attribute("underline-dotted", "text-decoration-style: dotted")
+		case UnderlineDashed:
+			attribute("underline-dashed", "text-decoration-style: dashed")
+		}
+	}
+
+	if es.Blink || es.FastBlink {
+		// no inline style for it
+		attribute("blink", "")
+	}
+	if es.Hidden {
+		attribute("hidden", "visibility: hidden")
+	}
+	if es.Framed {
+		attribute("framed", "border: 1px solid")
+	}
+	if es.Encircled {
+		attribute("encircled", "border: 1px solid; border-radius: 50%")
+	}
+	if es.Superscript {
+		attribute("superscript", "vertical-align: super; font-size: smaller")
+	}
+	if es.Subscript {
+		attribute("subscript", "vertical-align: sub; font-size: smaller")
+	}
+
+	fg, bg := es.FgColor, es.BgColor
+	if es.Reverse {
+		fg, bg = bg, fg
+		if fg == nil {
+			fg = ColorIndex(30)
+		}
+		if bg == nil {
+			bg = ColorIndex(47)
+		}
+	}
+
+	color := func(c Color, property string, ground string) {
+		if index := paletteIndex(c); index >= 0 && opts.classes {
+			classes = append(classes, fmt.Sprintf("%s%s-%d", opts.classPrefix, ground, index))
+		} else if css := cssColor(c, &opts.palette); css != "" {
+			styles = append(styles, property+": "+css)
+		}
+	}
+
+	color(fg, "color", "fg")
+	color(bg, "background-color", "bg")
+	if css := cssColor(es.UnderlineColor, &opts.palette); css != "" {
+		// not part of the palette classes
+		styles = append(styles, "text-decoration-color: "+css)
+	}
+
+	return classes, styles
+}
+
+// paletteIndex return the index of a color in the 16 basic colors, or -1 if
+// it's not one of them.
+func paletteIndex(c Color) int {
+	switch c := c.(type) {
+	case ColorIndex:
+		switch {
+		case c >= 30 && c <= 37:
+			return int(c) - 30
+		case c >= 40 && c <= 47:
+			return int(c) - 40
+		case c >= 90 && c <= 97:
+			return int(c) - 90 + 8
+		case c >= 100 && c <= 107:
+			return int(c) - 100 + 8
+		}
+	case *Color256:
+		if c.Index >= 0 && c.Index < 16 {
+			return c.Index
+		}
+	}
+	return -1
+}
+
+// cssColor return the CSS value of a color, with the given palette for the
+// 16 basic colors, or nothing for a nil or invalid color.
+func cssColor(c Color, palette *[16]string) string {
+	if index := paletteIndex(c); index >= 0 {
+		return palette[index]
+	}
+	switch c := c.(type) {
+	case *Color256:
+		if c.Index >= 16 && c.Index <= 255 {
+			return rgbCSS(color256RGB(c.Index))
+		}
+	case *ColorRGB:
+		return rgbCSS(c.R, c.G, c.B)
+	}
+	return ""
+}
+
+// rgbCSS return the CSS hexadecimal notation of a RGB color.
+func rgbCSS(r, g, b int) string {
+	clamp := func(v int) int {
+		if v < 0 {
+			return 0
+		}
+		if v > 255 {
+			return 255
+		}
+		return v
+	}
+	return fmt.Sprintf("#%02x%02x%02x", clamp(r), clamp(g), clamp(b))
+}
+
+// safeURL return true if the URL of a hyperlink can be used in HTML, excluding
+// the schemes able to run code like "javascript:".
+func safeURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto", "ftp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToHTML(t *testing.T) {
+	cases := []struct {
+		input  string
+		opts   []HTMLOption
+		output string
+	}{
+		{"", nil, ""},
+		{"a <b> & \"c\"", nil, "a &lt;b&gt; &amp; &#34;c&#34;"},
+		{"\x1b[1;31mfoo\x1b[0m bar", nil, `<span style="font-weight: bold; color: #cd0000">foo</span> bar`},
+		{"\x1b[31mfoo\x1b[1mbar", nil, `<span style="color: #cd0000">foo</span><span style="font-weight: bold; color: #cd0000">bar</span>`},
+		// the lines are self-contained
+		{"\x1b[32mfoo\nbar\x1b[0m\n", nil, `<span style="color: #00cd00">foo</span>` + "\n" + `<span style="color: #00cd00">bar</span>` + "\n"},
+		// 256 and RGB colors
+		{"\x1b[38;5;196;48;5;9mfoo", nil, `<span style="color: #ff0000; background-color: #ff0000">foo</span>`},
+		{"\x1b[48;2;1;2;3;97mfoo", nil, `<span style="color: #ffffff; background-color: #010203">foo</span>`},
+		// decorations
+		{"\x1b[4:3;9;53;58;5;232mfoo", nil, `<span style="text-decoration-line: underline overline line-through; text-decoration-style: wavy; text-decoration-color: #080808">foo</span>`},
+		// reverse video
+		{"\x1b[7mfoo", nil, `<span style="color: #000000; background-color: #e5e5e5">foo</span>`},
+		{"\x1b[7;31mfoo", nil, `<span style="color: #000000; background-color: #cd0000">foo</span>`},
+		// hyperlinks, only with safe schemes
+		{"\x1b]8;;https://example.com/?a=1&b=2\x1b\\foo\x1b]8;;\x1b\\", nil, `<a href="https://example.com/?a=1&amp;b=2">foo</a>`},
+		{"\x1b]8;;javascript:alert(1)\x1b\\foo\x1b]8;;\x1b\\", nil, "foo"},
+		// tabs are expanded, other sequences and controls removed
+		{"a\tb\x1b[2K\r\x07", nil, "a   b"},
+		// configurable palette
+		{"\x1b[31;104mfoo", []HTMLOption{HTMLPalette([16]string{"black", "red", "", "", "", "", "", "", "", "", "", "", "lightblue"})},
+			`<span style="color: red; background-color: lightblue">foo</span>`},
+		// CSS classes
+		{"\x1b[1;4:2;9;31;48;5;100mfoo", []HTMLOption{HTMLClasses("t-")},
+			`<span class="t-bold t-underline t-crossed-out t-underline-double t-fg-1" style="background-color: #878700">foo</span>`},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, ToHTML(c.input, c.opts...), "input %q", c.input)
+	}
+}
+
+func TestToHTMLWrap(t *testing.T) {
+	wrapped, _ := Wrap("The \x1b[1mquick brown\x1b[0m fox", 10, WrapPad("> "))
+	expected := "&gt; The\n" +
+		`&gt; <span style="font-weight: bold">quick</span>` + "\n" +
+		`&gt; <span style="font-weight: bold">brown</span>` + "\n" +
+		"&gt; fox"
+	assert.Equal(t, expected, ToHTML(wrapped))
+}
+
+func TestHTMLStylesheet(t *testing.T) {
+	css := HTMLStylesheet(HTMLClasses("t-"))
+	assert.Contains(t, css, ".t-bold { font-weight: bold; }\n")
+	assert.Contains(t, css, ".t-underline.t-crossed-out { text-decoration-line: underline line-through; }\n")
+	assert.Contains(t, css, ".t-fg-9 { color: #ff0000; }\n")
+	assert.True(t, strings.HasSuffix(css, "@keyframes t-blink { 50% { visibility: hidden; } }\n"))
+}
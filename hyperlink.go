@@ -0,0 +1,56 @@
+package text
+
+import "strings"
+
+// hyperlinkClose is the OSC 8 sequence closing the active hyperlink.
+const hyperlinkClose = "\x1b]8;;\x1b\\"
+
+// Hyperlink is a terminal hyperlink, as emitted with OSC 8:
+// "\x1b]8;params;url\x1b\\label\x1b]8;;\x1b\\".
+// The zero value means no active hyperlink.
+type Hyperlink struct {
+	// Params are optional "key=value" pairs separated by ':', like "id=foo".
+	Params string
+	URL    string
+}
+
+// IsZero return true if this Hyperlink is not an active link.
+func (h Hyperlink) IsZero() bool {
+	return h.URL == ""
+}
+
+// OpenString return the escape sequence opening this hyperlink, or nothing
+// if this is not an active link.
+func (h Hyperlink) OpenString() string {
+	if h.IsZero() {
+		return ""
+	}
+	return "\x1b]8;" + h.Params + ";" + h.URL + "\x1b\\"
+}
+
+// CloseString return the escape sequence closing this hyperlink, or nothing
+// if this is not an active link.
+func (h Hyperlink) CloseString() string {
+	if h.IsZero() {
+		return ""
+	}
+	return hyperlinkClose
+}
+
+// parseHyperlink decode an OSC 8 escape sequence. The second value is false if
+// seq is not an OSC 8 sequence.
+func parseHyperlink(seq string) (Hyperlink, bool) {
+	payload, ok := oscPayload(seq)
+	if !ok || !strings.HasPrefix(payload, "8;") {
+		return Hyperlink{}, false
+	}
+	split := strings.SplitN(payload[2:], ";", 2)
+	if len(split) < 2 {
+		return Hyperlink{}, false
+	}
+	if split[1] == "" {
+		// closing a link, params are meaningless
+		return Hyperlink{}, true
+	}
+	return Hyperlink{Params: split[0], URL: split[1]}, true
+}
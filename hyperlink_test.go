@@ -0,0 +1,38 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHyperlink(t *testing.T) {
+	cases := []struct {
+		input string
+		link  Hyperlink
+		ok    bool
+	}{
+		{"\x1b]8;;https://example.com\x1b\\", Hyperlink{URL: "https://example.com"}, true},
+		{"\x1b]8;id=foo;https://example.com\x07", Hyperlink{Params: "id=foo", URL: "https://example.com"}, true},
+		{"\x1b]8;;https://example.com/a;b\x1b\\", Hyperlink{URL: "https://example.com/a;b"}, true},
+		{"\x1b]8;;\x1b\\", Hyperlink{}, true},
+		{"\x1b]8;id=foo;\x1b\\", Hyperlink{}, true},
+		{"\x1b]0;title\x07", Hyperlink{}, false},
+		{"\x1b[31m", Hyperlink{}, false},
+	}
+
+	for _, tc := range cases {
+		link, ok := parseHyperlink(tc.input)
+		assert.Equal(t, tc.link, link)
+		assert.Equal(t, tc.ok, ok)
+	}
+}
+
+func TestHyperlinkStrings(t *testing.T) {
+	link := Hyperlink{Params: "id=foo", URL: "https://example.com"}
+	assert.Equal(t, "\x1b]8;id=foo;https://example.com\x1b\\", link.OpenString())
+	assert.Equal(t, "\x1b]8;;\x1b\\", link.CloseString())
+
+	assert.Equal(t, "", Hyperlink{}.OpenString())
+	assert.Equal(t, "", Hyperlink{}.CloseString())
+}
@@ -0,0 +1,358 @@
+package text
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// softHyphen is an invisible hyphenation point (U+00AD). When a line is broken
+// there, it's rendered as a visible hyphen.
+const softHyphen = '\u00ad'
+
+// Hyphenator find the hyphenation points of words, using Frank M. Liang's
+// algorithm with TeX hyphenation patterns.
+type Hyphenator struct {
+	// LeftMin and RightMin are the minimal number of letters to keep before
+	// and after a hyphen.
+	LeftMin, RightMin int
+
+	patterns      map[string][]int
+	maxPatternLen int
+	exceptions    map[string][]int
+}
+
+var englishHyphenator struct {
+	once sync.Once
+	h    *Hyphenator
+}
+
+// EnglishHyphenator return a Hyphenator using the bundled English (US)
+// patterns and exceptions.
+func EnglishHyphenator() *Hyphenator {
+	englishHyphenator.once.Do(func() {
+		h, err := NewHyphenator(strings.NewReader(patternsEnUS), strings.NewReader(exceptionsEnUS))
+		if err != nil {
+			panic(err)
+		}
+		englishHyphenator.h = h
+	})
+	return englishHyphenator.h
+}
+
+// NewHyphenator build a Hyphenator from TeX hyphenation patterns and exceptions,
+// in the whitespace separated format of the hyph-utf8 .pat.txt and .hyp.txt
+// files: patterns like "a1b" or ".ach4", exceptions like "ta-ble".
+// Exceptions are optional and can be nil.
+func NewHyphenator(patterns io.Reader, exceptions io.Reader) (*Hyphenator, error) {
+	h := &Hyphenator{
+		LeftMin:    2,
+		RightMin:   3,
+		patterns:   make(map[string][]int),
+		exceptions: make(map[string][]int),
+	}
+
+	err := scanWords(patterns, func(word string) error {
+		return h.addPattern(word)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if exceptions != nil {
+		err = scanWords(exceptions, func(word string) error {
+			h.addException(word)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// ParseTeXHyphenation build a Hyphenator from a TeX hyphenation file, like
+// the hyph-*.tex files of hyph-utf8, holding the patterns in a \patterns{}
+// block and optionally the exceptions in a \hyphenation{} block.
+// Comments are ignored, other commands are not supported.
+func ParseTeXHyphenation(r io.Reader) (*Hyphenator, error) {
+	var patterns, exceptions strings.Builder
+	var block *strings.Builder
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexRune(line, '%'); i >= 0 {
+			line = line[:i]
+		}
+		for line != "" {
+			switch {
+			case block == nil && strings.HasPrefix(line, `\patterns{`):
+				block = &patterns
+				line = line[len(`\patterns{`):]
+			case block == nil && strings.HasPrefix(line, `\hyphenation{`):
+				block = &exceptions
+				line = line[len(`\hyphenation{`):]
+			case block == nil:
+				// anything else outside of the blocks is ignored
+				line = ""
+			default:
+				i := strings.IndexRune(line, '}')
+				if i < 0 {
+					block.WriteString(line)
+					block.WriteString("\n")
+					line = ""
+					continue
+				}
+				block.WriteString(line[:i])
+				block.WriteString("\n")
+				block = nil
+				line = strings.TrimSpace(line[i+1:])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if patterns.Len() == 0 {
+		return nil, fmt.Errorf("no hyphenation patterns found")
+	}
+
+	return NewHyphenator(strings.NewReader(patterns.String()), strings.NewReader(exceptions.String()))
+}
+
+// scanWords call fn for each whitespace separated word of r.
+func scanWords(r io.Reader, fn func(word string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// addPattern register a pattern like "a1b" or ".ach4". Its values are the
+// digits between the letters, missing ones being 0.
+func (h *Hyphenator) addPattern(pattern string) error {
+	var letters []rune
+	values := []int{0}
+
+	for _, r := range pattern {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = int(r - '0')
+			continue
+		}
+		letters = append(letters, unicode.ToLower(r))
+		values = append(values, 0)
+	}
+	if len(letters) == 0 {
+		return fmt.Errorf("invalid hyphenation pattern %q", pattern)
+	}
+
+	h.patterns[string(letters)] = values
+	if len(letters) > h.maxPatternLen {
+		h.maxPatternLen = len(letters)
+	}
+	return nil
+}
+
+// addException register a word with its explicit hyphenation points, like "ta-ble".
+func (h *Hyphenator) addException(word string) {
+	var letters []rune
+	var points []int
+
+	for _, r := range word {
+		if r == '-' {
+			points = append(points, len(letters))
+			continue
+		}
+		letters = append(letters, unicode.ToLower(r))
+	}
+
+	h.exceptions[string(letters)] = points
+}
+
+// Hyphenate split a word into its syllables, at the places where a hyphen
+// can be inserted. For example "algorithm" gives ["al", "go", "rithm"].
+func (h *Hyphenator) Hyphenate(word string) []string {
+	runes := []rune(word)
+	points := h.points(runes)
+
+	result := make([]string, 0, len(points)+1)
+	last := 0
+	for _, p := range points {
+		result = append(result, string(runes[last:p]))
+		last = p
+	}
+	return append(result, string(runes[last:]))
+}
+
+// points return the rune index in word where a hyphen can be inserted.
+func (h *Hyphenator) points(word []rune) []int {
+	if len(word) < h.LeftMin+h.RightMin {
+		return nil
+	}
+
+	lower := make([]rune, len(word))
+	for i, r := range word {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var points []int
+
+	if exception, ok := h.exceptions[string(lower)]; ok {
+		for _, p := range exception {
+			if p >= h.LeftMin && len(word)-p >= h.RightMin {
+				points = append(points, p)
+			}
+		}
+		return points
+	}
+
+	// values[i] is the value of the gap before dotted[i]
+	dotted := append(append([]rune{'.'}, lower...), '.')
+	values := make([]int, len(dotted)+1)
+
+	for i := range dotted {
+		for l := 1; l <= h.maxPatternLen && i+l <= len(dotted); l++ {
+			pattern, ok := h.patterns[string(dotted[i:i+l])]
+			if !ok {
+				continue
+			}
+			for k, v := range pattern {
+				if v > values[i+k] {
+					values[i+k] = v
+				}
+			}
+		}
+	}
+
+	// the gap before word[p] is the gap before dotted[p+1]
+	for p := h.LeftMin; p <= len(word)-h.RightMin; p++ {
+		if values[p+1]%2 == 1 {
+			points = append(points, p)
+		}
+	}
+
+	return points
+}
+
+// insertSoftHyphens insert a soft hyphen at each hyphenation point of the words
+// of a line, while ignoring the terminal escape sequences. Words that already
+// contain a soft hyphen are left untouched.
+func (h *Hyphenator) insertSoftHyphens(line string) string {
+	cleaned, escapes := ExtractTermEscapes(line)
+	runes := []rune(cleaned)
+
+	var out []rune
+	// newPos[i] is the index in out of runes[i]
+	newPos := make([]int, len(runes)+1)
+
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) {
+			newPos[i] = len(out)
+			out = append(out, runes[i])
+			i++
+			continue
+		}
+
+		end := i
+		shy := false
+		for end < len(runes) && (unicode.IsLetter(runes[end]) || runes[end] == softHyphen) {
+			shy = shy || runes[end] == softHyphen
+			end++
+		}
+
+		var points []int
+		if !shy {
+			points = h.points(runes[i:end])
+		}
+		for j := i; j < end; j++ {
+			if len(points) > 0 && j-i == points[0] {
+				out = append(out, softHyphen)
+				points = points[1:]
+			}
+			newPos[j] = len(out)
+			out = append(out, runes[j])
+		}
+		i = end
+	}
+	newPos[len(runes)] = len(out)
+
+	for i := range escapes {
+		escapes[i].Pos = newPos[escapes[i].Pos]
+	}
+
+	return ApplyTermEscapes(string(out), escapes)
+}
+
+// renderSoftHyphens prepare a wrapped line for display: the soft hyphens are
+// removed, except at the end of a line broken there, where it's replaced by a
+// visible hyphen.
+func renderSoftHyphens(line string, broken bool) string {
+	if !strings.ContainsRune(line, softHyphen) {
+		return line
+	}
+
+	last := -1
+	if broken {
+		// the line is broken after its last soft hyphen if only escapes follow it
+		i := strings.LastIndex(line, string(softHyphen))
+		tail := line[i+utf8.RuneLen(softHyphen):]
+		if Len(tail) == 0 {
+			last = i
+		}
+	}
+
+	var out strings.Builder
+	for i, r := range line {
+		switch {
+		case i == last:
+			out.WriteRune('-')
+		case r == softHyphen:
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// splitSoftHyphens split the chunks of a line after each soft hyphen, so that
+// the line can be broken there. A chunk made only of soft hyphens is merged
+// into the preceding one.
+func splitSoftHyphens(chunks []string) []string {
+	var result []string
+	add := func(piece string) {
+		if strings.Trim(piece, string(softHyphen)) == "" && len(result) > 0 {
+			result[len(result)-1] += piece
+			return
+		}
+		result = append(result, piece)
+	}
+	for _, chunk := range chunks {
+		for chunk != "" {
+			i := strings.IndexRune(chunk, softHyphen)
+			if i < 0 {
+				add(chunk)
+				break
+			}
+			i += utf8.RuneLen(softHyphen)
+			add(chunk[:i])
+			chunk = chunk[i:]
+		}
+	}
+	return result
+}
+
+// endsWithSoftHyphen return true if breaking the line after this chunk would
+// display a hyphen.
+func endsWithSoftHyphen(chunk string) bool {
+	return strings.HasSuffix(chunk, string(softHyphen))
+}
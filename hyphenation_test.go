@@ -0,0 +1,95 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyphenate(t *testing.T) {
+	cases := []struct {
+		input  string
+		output []string
+	}{
+		{"algorithm", []string{"al", "go", "rithm"}},
+		{"computer", []string{"com", "puter"}},
+		{"Computer", []string{"Com", "puter"}},
+		{"table", []string{"ta", "ble"}},
+		{"project", []string{"project"}},
+		{"a", []string{"a"}},
+		{"", []string{""}},
+		// from the exceptions
+		{"academies", []string{"acad", "e", "mies"}},
+	}
+
+	h := EnglishHyphenator()
+	for _, tc := range cases {
+		assert.Equal(t, tc.output, h.Hyphenate(tc.input), tc.input)
+	}
+}
+
+func TestNewHyphenator(t *testing.T) {
+	h, err := NewHyphenator(strings.NewReader("a1b\n c2d1e"), strings.NewReader("ab-cd-ef"))
+	assert.NoError(t, err)
+	h.LeftMin, h.RightMin = 1, 1
+
+	assert.Equal(t, []string{"xa", "bx"}, h.Hyphenate("xabx"))
+	assert.Equal(t, []string{"xcd", "ex"}, h.Hyphenate("xcdex"))
+	assert.Equal(t, []string{"ab", "cd", "ef"}, h.Hyphenate("abcdef"))
+
+	_, err = NewHyphenator(strings.NewReader("a1b 12"), nil)
+	assert.Error(t, err)
+}
+
+func TestParseTeXHyphenation(t *testing.T) {
+	input := `% a comment
+\patterns{ % another comment
+a1b
+c2d1e
+}
+\hyphenation{
+ab-cd-ef
+}`
+
+	h, err := ParseTeXHyphenation(strings.NewReader(input))
+	assert.NoError(t, err)
+	h.LeftMin, h.RightMin = 1, 1
+
+	assert.Equal(t, []string{"xa", "bx"}, h.Hyphenate("xabx"))
+	assert.Equal(t, []string{"xcd", "ex"}, h.Hyphenate("xcdex"))
+	assert.Equal(t, []string{"ab", "cd", "ef"}, h.Hyphenate("abcdef"))
+
+	_, err = ParseTeXHyphenation(strings.NewReader("% nothing"))
+	assert.Error(t, err)
+}
+
+func TestInsertSoftHyphens(t *testing.T) {
+	cases := []struct {
+		input, output string
+	}{
+		{"algorithm", "al\u00adgo\u00adrithm"},
+		{"an algorithm.", "an al\u00adgo\u00adrithm."},
+		{"al\x1b[31mgorithm", "al\u00ad\x1b[31mgo\u00adrithm"},
+		// existing soft hyphens are kept as the only hyphenation points
+		{"algo\u00adrithm", "algo\u00adrithm"},
+	}
+
+	h := EnglishHyphenator()
+	for _, tc := range cases {
+		assert.Equal(t, tc.output, h.insertSoftHyphens(tc.input), tc.input)
+	}
+}
+
+func TestRenderSoftHyphens(t *testing.T) {
+	assert.Equal(t, "algo-", renderSoftHyphens("al\u00adgo\u00ad", true))
+	assert.Equal(t, "algo-\x1b[0m", renderSoftHyphens("al\u00adgo\u00ad\x1b[0m", true))
+	assert.Equal(t, "algo", renderSoftHyphens("al\u00adgo\u00ad", false))
+	assert.Equal(t, "algorithm", renderSoftHyphens("al\u00adgo\u00adrithm", true))
+}
+
+func TestSplitSoftHyphens(t *testing.T) {
+	chunks := []string{"al\u00adgo\u00adrithm", " ", "a", "\u00ad", "bc"}
+	expected := []string{"al\u00ad", "go\u00ad", "rithm", " ", "a\u00ad", "bc"}
+	assert.Equal(t, expected, splitSoftHyphens(chunks))
+}
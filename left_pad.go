@@ -3,27 +3,31 @@ package text
 import (
 	"bytes"
 	"strings"
-
-	"github.com/mattn/go-runewidth"
 )
 
 // LeftPadMaxLine pads a line on the left by a specified amount and pads the
 // string on the right to fill the maxLength.
-// If the given string is too long, it is truncated with an ellipsis.
+// If the given string is too long, it is truncated with an ellipsis (see
+// TruncateMax).
 // Handle properly terminal color escape code
-func LeftPadMaxLine(line string, length, leftPad int) string {
-	cleaned, escapes := ExtractTermEscapes(line)
+// Tabs are expanded into spaces, the tab stops starting after the left pad
+// (see TabSize).
+func LeftPadMaxLine(line string, length, leftPad int, opts ...TabOption) string {
+	line = expandTabs(line, leftPad, tabWidth(opts))
+	pad := strings.Repeat(" ", leftPad)
 
-	scrWidth := runewidth.StringWidth(cleaned)
-	// truncate and ellipse if needed
+	scrWidth := Len(line)
+	// truncate and ellipse if needed, with the style closed at the cut
 	if scrWidth+leftPad > length {
-		cleaned = runewidth.Truncate(cleaned, length-leftPad, "…")
-	} else if scrWidth+leftPad < length {
-		cleaned = runewidth.FillRight(cleaned, length-leftPad)
+		return pad + TruncateMax(line, length-leftPad)
+	}
+
+	cleaned, escapes := ExtractTermEscapes(line)
+	if scrWidth+leftPad < length {
+		cleaned += strings.Repeat(" ", length-leftPad-scrWidth)
 	}
 
 	rightPart := ApplyTermEscapes(cleaned, escapes)
-	pad := strings.Repeat(" ", leftPad)
 
 	return pad + rightPart
 }
@@ -52,12 +52,32 @@ func TestLeftPadMaxLine(t *testing.T) {
 			10,
 			2,
 		},
+		// the style is closed before the ellipsis
 		{
 			"\x1b[31mfoofoobar\x1b[0m",
-			"  \x1b[31mfo…\x1b[0m",
+			"  \x1b[31mfo\x1b[0m…",
 			5,
 			2,
 		},
+		{
+			"\x1b[1mfoo\x1b[32mfoobar",
+			"  \x1b[1mfoo\x1b[0m…",
+			6,
+			2,
+		},
+		// tab stops start after the left pad
+		{
+			"a\tb",
+			"  a b     ",
+			10,
+			2,
+		},
+		{
+			"a\tb\tc",
+			" a  b  …",
+			8,
+			1,
+		},
 	}
 
 	for i, tc := range cases {
@@ -2,40 +2,42 @@ package text
 
 import (
 	"strings"
-
-	"github.com/mattn/go-runewidth"
 )
 
 // Len return the length of a string in a terminal, while ignoring the terminal
-// escape sequences.
-func Len(text string) int {
+// escape sequences. Extended grapheme clusters (combining marks, emoji sequences,
+// flags ...) are measured as a whole. Tabs advance to the next tab stop (see TabSize).
+func Len(text string, opts ...TabOption) int {
+	tabWidth := tabWidth(opts)
 	length := 0
-	escape := false
-
-	for _, char := range text {
-		if char == '\x1b' {
-			escape = true
-		}
-		if !escape {
-			length += runewidth.RuneWidth(char)
+	col := 0
+
+	walkGraphemes(text, func(str string, _ bool, width int) bool {
+		switch {
+		case str == "\t":
+			width = tabStopWidth(col, tabWidth)
+		case strings.HasSuffix(str, "\n"):
+			// "\n" or "\r\n"
+			col = 0
 		}
-		if char == 'm' {
-			escape = false
-		}
-	}
+		length += width
+		col += width
+		return true
+	})
 
 	return length
 }
 
 // MaxLineLen return the length in a terminal of the longest line, while
-// ignoring the terminal escape sequences.
-func MaxLineLen(text string) int {
+// ignoring the terminal escape sequences. Tabs advance to the next tab stop
+// (see TabSize).
+func MaxLineLen(text string, opts ...TabOption) int {
 	lines := strings.Split(text, "\n")
 
 	max := 0
 
 	for _, line := range lines {
-		length := Len(line)
+		length := Len(line, opts...)
 		if length > max {
 			max = length
 		}
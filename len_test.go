@@ -61,6 +61,62 @@ func TestLen(t *testing.T) {
 			"❌ ",
 			3,
 		},
+		// Handle escapes that don't end with 'm'
+		{
+			"\x1b[2Kfoo\x1b[1;1H",
+			3,
+		},
+		{
+			"\x1b]0;window title\x07foo",
+			3,
+		},
+		{
+			"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\",
+			4,
+		},
+		{
+			"\x1b(Bfoo\u009b31mbar",
+			6,
+		},
+		// Tabs advance to the next tab stop
+		{
+			"\tfoo",
+			7,
+		},
+		{
+			"foo\tbar\t",
+			8,
+		},
+		{
+			"foobar\x1b[31m\t\x1b[0m敏捷\t",
+			16,
+		},
+		{
+			"ab\r\n\tx",
+			7,
+		},
+		// Handle grapheme clusters
+		{
+			"e\u0301t\u00e9",
+			3,
+		},
+		{
+			"\U0001f468\u200d\U0001f469\u200d\U0001f467 family",
+			9,
+		},
+		{
+			"\U0001f1eb\U0001f1f7\U0001f44d\U0001f3fd",
+			4,
+		},
+		// A cluster right after a control character
+		{
+			"a\u200b\U0001f468\u200d\U0001f469\u200d\U0001f467",
+			3,
+		},
+		{
+			"\n\U0001f468\u200d\U0001f469\u200d\U0001f467",
+			2,
+		},
 	}
 
 	for i, tc := range cases {
@@ -92,7 +148,7 @@ func TestMaxLineLen(t *testing.T) {
 			59,
 		},
 		{
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 			12,
 		},
 	}
@@ -0,0 +1,411 @@
+package text
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LineBreak select how Wrap() find the places where a line can be broken.
+type LineBreak int
+
+const (
+	// LineBreakSimple break lines around groups of spaces and wide characters.
+	LineBreakSimple LineBreak = iota
+	// LineBreakUnicode break lines following the Unicode line breaking
+	// algorithm (UAX #14): after hyphens, slashes or dashes, never before
+	// closing punctuation, never around non-breaking spaces or word joiners,
+	// and always at the mandatory breaks (line/paragraph separators, form feeds ...).
+	LineBreakUnicode
+)
+
+// lbClass is a line breaking class, as defined by UAX #14.
+// Only the classes that matter for a terminal are kept, the others being
+// resolved as described in the rule LB1.
+type lbClass int
+
+const (
+	lbAL lbClass = iota // alphabetic, and the default for unknown characters
+	lbBA                // break after
+	lbBB                // break before
+	lbB2                // break opportunity before and after
+	lbBK                // mandatory break
+	lbCL                // close punctuation
+	lbCP                // close parenthesis
+	lbCR                // carriage return
+	lbEX                // exclamation / interrogation
+	lbGL                // non-breaking ("glue")
+	lbHY                // hyphen
+	lbID                // ideographic, including emojis
+	lbIN                // inseparable
+	lbIS                // infix numeric separator
+	lbLF                // line feed
+	lbNS                // non-starter, including small kana
+	lbNU                // numeric
+	lbOP                // open punctuation
+	lbPO                // postfix numeric
+	lbPR                // prefix numeric
+	lbQU                // quotation
+	lbSP                // space
+	lbSY                // symbols allowing break after
+	lbWJ                // word joiner
+	lbZW                // zero width space
+)
+
+type lbRange struct {
+	lo, hi rune
+	class  lbClass
+}
+
+// lbRanges hold the characters whose class can't be derived from their
+// general category. It must be kept sorted.
+var lbRanges = []lbRange{
+	{'\t', '\t', lbBA},
+	{'\n', '\n', lbLF},
+	{'\v', '\f', lbBK},
+	{'\r', '\r', lbCR},
+	{' ', ' ', lbSP},
+	{'!', '!', lbEX},
+	{'"', '"', lbQU},
+	{'$', '$', lbPR},
+	{'%', '%', lbPO},
+	{'\'', '\'', lbQU},
+	{'(', '(', lbOP},
+	{')', ')', lbCP},
+	{'+', '+', lbPR},
+	{',', ',', lbIS},
+	{'-', '-', lbHY},
+	{'.', '.', lbIS},
+	{'/', '/', lbSY},
+	{':', ';', lbIS},
+	{'?', '?', lbEX},
+	{'[', '[', lbOP},
+	{'\\', '\\', lbPR},
+	{']', ']', lbCP},
+	{'{', '{', lbOP},
+	{'|', '|', lbBA},
+	{'}', '}', lbCL},
+	{0x00A0, 0x00A0, lbGL},
+	{0x00A1, 0x00A1, lbOP},
+	{0x00A2, 0x00A2, lbPO},
+	{0x00A3, 0x00A5, lbPR},
+	{0x00AB, 0x00AB, lbQU},
+	{0x00AD, 0x00AD, lbBA},
+	{0x00B0, 0x00B0, lbPO},
+	{0x00B1, 0x00B1, lbPR},
+	{0x00B4, 0x00B4, lbBB},
+	{0x00BB, 0x00BB, lbQU},
+	{0x00BF, 0x00BF, lbOP},
+	{0x02C8, 0x02C8, lbBB},
+	{0x02CC, 0x02CC, lbBB},
+	{0x02DF, 0x02DF, lbBB},
+	{0x034F, 0x034F, lbGL},
+	{0x037E, 0x037E, lbIS},
+	{0x0589, 0x0589, lbIS},
+	{0x058A, 0x058A, lbBA},
+	{0x05BE, 0x05BE, lbBA},
+	{0x05C6, 0x05C6, lbEX},
+	{0x060C, 0x060D, lbIS},
+	{0x061B, 0x061B, lbEX},
+	{0x061E, 0x061F, lbEX},
+	{0x066A, 0x066A, lbPO},
+	{0x06D4, 0x06D4, lbEX},
+	{0x0E3F, 0x0E3F, lbPR},
+	{0x0F08, 0x0F08, lbGL},
+	{0x0F0C, 0x0F0C, lbGL},
+	{0x0F0D, 0x0F11, lbEX},
+	{0x0F12, 0x0F12, lbGL},
+	{0x1680, 0x1680, lbBA},
+	{0x17D6, 0x17D6, lbNS},
+	{0x17DB, 0x17DB, lbPR},
+	{0x180E, 0x180E, lbGL},
+	{0x1FFD, 0x1FFD, lbBB},
+	{0x2000, 0x2006, lbBA},
+	{0x2007, 0x2007, lbGL},
+	{0x2008, 0x200A, lbBA},
+	{0x200B, 0x200B, lbZW},
+	{0x2010, 0x2010, lbBA},
+	{0x2011, 0x2011, lbGL},
+	{0x2012, 0x2013, lbBA},
+	{0x2014, 0x2014, lbB2},
+	{0x2018, 0x2019, lbQU},
+	{0x201B, 0x201D, lbQU},
+	{0x201F, 0x201F, lbQU},
+	{0x2024, 0x2026, lbIN},
+	{0x2027, 0x2027, lbBA},
+	{0x2028, 0x2029, lbBK},
+	{0x202F, 0x202F, lbGL},
+	{0x2030, 0x2037, lbPO},
+	{0x2039, 0x203A, lbQU},
+	{0x203C, 0x203D, lbNS},
+	{0x2044, 0x2044, lbIS},
+	{0x2047, 0x2049, lbNS},
+	{0x2056, 0x2056, lbBA},
+	{0x2058, 0x205B, lbBA},
+	{0x205D, 0x205F, lbBA},
+	{0x2060, 0x2060, lbWJ},
+	{0x20A0, 0x20A6, lbPR},
+	{0x20A7, 0x20A7, lbPO},
+	{0x20A8, 0x20B5, lbPR},
+	{0x20B6, 0x20B6, lbPO},
+	{0x20B7, 0x20BA, lbPR},
+	{0x20BB, 0x20BB, lbPO},
+	{0x20BC, 0x20BD, lbPR},
+	{0x20BE, 0x20BE, lbPO},
+	{0x20BF, 0x20CF, lbPR},
+	{0x2103, 0x2103, lbPO},
+	{0x2109, 0x2109, lbPO},
+	{0x2116, 0x2116, lbPR},
+	{0x2212, 0x2213, lbPR},
+	{0x2E3A, 0x2E3B, lbB2},
+	{0x3000, 0x3000, lbBA},
+	{0x3001, 0x3002, lbCL},
+	{0x3005, 0x3005, lbNS},
+	{0x301C, 0x301C, lbNS},
+	{0x303B, 0x303C, lbNS},
+	{0x3041, 0x3041, lbNS},
+	{0x3043, 0x3043, lbNS},
+	{0x3045, 0x3045, lbNS},
+	{0x3047, 0x3047, lbNS},
+	{0x3049, 0x3049, lbNS},
+	{0x3063, 0x3063, lbNS},
+	{0x3083, 0x3083, lbNS},
+	{0x3085, 0x3085, lbNS},
+	{0x3087, 0x3087, lbNS},
+	{0x308E, 0x308E, lbNS},
+	{0x3095, 0x3096, lbNS},
+	{0x309B, 0x309E, lbNS},
+	{0x30A0, 0x30A1, lbNS},
+	{0x30A3, 0x30A3, lbNS},
+	{0x30A5, 0x30A5, lbNS},
+	{0x30A7, 0x30A7, lbNS},
+	{0x30A9, 0x30A9, lbNS},
+	{0x30C3, 0x30C3, lbNS},
+	{0x30E3, 0x30E3, lbNS},
+	{0x30E5, 0x30E5, lbNS},
+	{0x30E7, 0x30E7, lbNS},
+	{0x30EE, 0x30EE, lbNS},
+	{0x30F5, 0x30F6, lbNS},
+	{0x30FB, 0x30FE, lbNS},
+	{0x31F0, 0x31FF, lbNS},
+	{0xFE10, 0xFE10, lbIS},
+	{0xFE11, 0xFE12, lbCL},
+	{0xFE13, 0xFE14, lbIS},
+	{0xFE15, 0xFE16, lbEX},
+	{0xFE19, 0xFE19, lbIN},
+	{0xFE50, 0xFE50, lbCL},
+	{0xFE52, 0xFE52, lbCL},
+	{0xFE54, 0xFE55, lbNS},
+	{0xFE56, 0xFE57, lbEX},
+	{0xFE69, 0xFE69, lbPR},
+	{0xFE6A, 0xFE6A, lbPO},
+	{0xFEFF, 0xFEFF, lbWJ},
+	{0xFF01, 0xFF01, lbEX},
+	{0xFF04, 0xFF04, lbPR},
+	{0xFF05, 0xFF05, lbPO},
+	{0xFF0C, 0xFF0C, lbCL},
+	{0xFF0E, 0xFF0E, lbCL},
+	{0xFF1A, 0xFF1B, lbNS},
+	{0xFF1F, 0xFF1F, lbEX},
+	{0xFF61, 0xFF61, lbCL},
+	{0xFF64, 0xFF64, lbCL},
+	{0xFF65, 0xFF65, lbNS},
+	{0xFF67, 0xFF70, lbNS},
+	{0xFF9E, 0xFF9F, lbNS},
+	{0xFFE0, 0xFFE0, lbPO},
+	{0xFFE1, 0xFFE1, lbPR},
+	{0xFFE5, 0xFFE6, lbPR},
+}
+
+// lineBreakClass return the line breaking class of a grapheme cluster.
+// As per the rule LB9, a cluster has the class of its first character.
+func lineBreakClass(cluster string, width int) lbClass {
+	r, _ := utf8.DecodeRuneInString(cluster)
+
+	i := sort.Search(len(lbRanges), func(i int) bool {
+		return lbRanges[i].hi >= r
+	})
+	if i < len(lbRanges) && lbRanges[i].lo <= r {
+		return lbRanges[i].class
+	}
+
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me, unicode.Cc):
+		// orphan combining marks are treated as alphabetic (LB10)
+		return lbAL
+	case unicode.Is(unicode.Ps, r):
+		return lbOP
+	case unicode.Is(unicode.Pe, r):
+		return lbCL
+	case unicode.In(r, unicode.Pi, unicode.Pf):
+		return lbQU
+	case width > 1:
+		return lbID
+	case unicode.Is(unicode.Nd, r):
+		return lbNU
+	}
+	return lbAL
+}
+
+// isMandatoryBreak return true if the given grapheme cluster force a line break.
+func isMandatoryBreak(cluster string) bool {
+	switch lineBreakClass(cluster, 0) {
+	case lbBK, lbCR, lbLF:
+		return true
+	}
+	return false
+}
+
+// splitMandatoryBreaks split a text at the mandatory breaks (UAX #14 rules
+// LB4 and LB5), "\r\n" being a single break. The breaking characters are
+// removed.
+// The text is scanned character by character rather than by grapheme cluster,
+// so that "\r\n" is recognized whatever comes before.
+func splitMandatoryBreaks(line string) []string {
+	var lines []string
+	start := 0
+	for i := 0; i < len(line); {
+		if n := escapeLen(line[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if isMandatoryBreak(string(r)) {
+			if r == '\r' && i+1 < len(line) && line[i+1] == '\n' {
+				size = 2
+			}
+			lines = append(lines, line[start:i])
+			start = i + size
+		}
+		i += size
+	}
+	return append(lines, line[start:])
+}
+
+// lineBreakAfter return true if the line can be broken between the clusters
+// i and i+1, following the pair rules of UAX #14 (LB6 to LB31).
+// Mandatory breaks are expected to be handled beforehand.
+func lineBreakAfter(classes []lbClass, i int) bool {
+	a, b := classes[i], classes[i+1]
+
+	// the class before a run of spaces, for the rules LB8 and LB14 to LB17
+	k := i
+	for k > 0 && classes[k] == lbSP {
+		k--
+	}
+	beforeSP := classes[k]
+
+	switch {
+	// LB6, LB7: no break before hard line breaks, spaces or ZWSP
+	case b == lbBK || b == lbCR || b == lbLF || b == lbSP || b == lbZW:
+		return false
+	// LB8: break after ZWSP, even followed by spaces
+	case beforeSP == lbZW:
+		return true
+	// LB11: no break around word joiners
+	case a == lbWJ || b == lbWJ:
+		return false
+	// LB12, LB12a: no break around non-breaking characters
+	case a == lbGL:
+		return false
+	case b == lbGL && a != lbSP && a != lbBA && a != lbHY:
+		return false
+	// LB13: no break before closing punctuation and the like
+	case b == lbCL || b == lbCP || b == lbEX || b == lbIS || b == lbSY:
+		return false
+	// LB14 to LB17: no break after opening punctuation, even after spaces
+	case beforeSP == lbOP:
+		return false
+	case beforeSP == lbQU && b == lbOP:
+		return false
+	case (beforeSP == lbCL || beforeSP == lbCP) && b == lbNS:
+		return false
+	case beforeSP == lbB2 && b == lbB2:
+		return false
+	// LB18: break after spaces
+	case a == lbSP:
+		return true
+	// LB19: no break around quotation marks
+	case a == lbQU || b == lbQU:
+		return false
+	// LB21: no break before hyphens and small kana, nor after BB
+	case b == lbBA || b == lbHY || b == lbNS || a == lbBB:
+		return false
+	// LB22: no break before ellipsis
+	case b == lbIN:
+		return false
+	// LB23 to LB25: keep numbers together with their prefix and postfix
+	case a == lbAL && b == lbNU, a == lbNU && b == lbAL:
+		return false
+	case a == lbPR && b == lbID, a == lbID && b == lbPO:
+		return false
+	case (a == lbPR || a == lbPO) && b == lbAL, a == lbAL && (b == lbPR || b == lbPO):
+		return false
+	case (a == lbCL || a == lbCP || a == lbNU) && (b == lbPO || b == lbPR):
+		return false
+	case (a == lbPO || a == lbPR) && (b == lbOP || b == lbNU):
+		return false
+	case (a == lbHY || a == lbIS || a == lbNU || a == lbSY) && b == lbNU:
+		return false
+	// LB28, LB29: no break inside words
+	case a == lbAL && b == lbAL, a == lbIS && b == lbAL:
+		return false
+	// LB30: no break between letters and parenthesis
+	case (a == lbAL || a == lbNU) && b == lbOP, a == lbCP && (b == lbAL || b == lbNU):
+		return false
+	}
+
+	// LB31: break everywhere else
+	return true
+}
+
+// segmentLineUnicode segment a line into non-breakable chunks following the
+// Unicode line breaking algorithm. Trailing spaces are split into their own
+// chunk so that they can be dropped at the end of a line.
+//
+// Required: The line shall not contain terminal escapes or mandatory breaks.
+func segmentLineUnicode(s string) []string {
+	var clusters []string
+	var classes []lbClass
+
+	walkGraphemes(s, func(cluster string, _ bool, width int) bool {
+		clusters = append(clusters, cluster)
+		classes = append(classes, lineBreakClass(cluster, width))
+		return true
+	})
+
+	var chunks []string
+	var word, spaces strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			chunks = append(chunks, word.String())
+		}
+		if spaces.Len() > 0 {
+			chunks = append(chunks, spaces.String())
+		}
+		word.Reset()
+		spaces.Reset()
+	}
+
+	nonSpace := false
+	for i, cluster := range clusters {
+		if classes[i] == lbSP && nonSpace {
+			spaces.WriteString(cluster)
+		} else {
+			// spaces followed by something else in the same chunk, like "( foo"
+			word.WriteString(spaces.String())
+			spaces.Reset()
+			word.WriteString(cluster)
+			nonSpace = nonSpace || classes[i] != lbSP
+		}
+		if i+1 < len(clusters) && lineBreakAfter(classes, i) {
+			flush()
+			nonSpace = false
+		}
+	}
+	flush()
+
+	return chunks
+}
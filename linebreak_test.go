@@ -0,0 +1,106 @@
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineBreakRangesSorted(t *testing.T) {
+	for i := 1; i < len(lbRanges); i++ {
+		assert.True(t, lbRanges[i-1].hi < lbRanges[i].lo, "%U", lbRanges[i].lo)
+		assert.True(t, lbRanges[i].lo <= lbRanges[i].hi, "%U", lbRanges[i].lo)
+	}
+}
+
+func TestLineBreakClass(t *testing.T) {
+	cases := []struct {
+		input string
+		class lbClass
+	}{
+		{"a", lbAL},
+		{"e\u0301", lbAL},
+		{"\u0301", lbAL},
+		{" ", lbSP},
+		{"-", lbHY},
+		{"/", lbSY},
+		{"(", lbOP},
+		{")", lbCP},
+		{"}", lbCL},
+		{"。", lbCL},
+		{"「", lbOP},
+		{"\u00a0", lbGL},
+		{"\u2060", lbWJ},
+		{"\u200b", lbZW},
+		{"—", lbB2},
+		{"\u2028", lbBK},
+		{"7", lbNU},
+		{"敏", lbID},
+		{"っ", lbNS},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.class, lineBreakClass(tc.input, Len(tc.input)), "%q", tc.input)
+	}
+}
+
+func TestSplitMandatoryBreaks(t *testing.T) {
+	assert.Equal(t, []string{"foo"}, splitMandatoryBreaks("foo"))
+	assert.Equal(t, []string{"foo", "bar", "baz", ""}, splitMandatoryBreaks("foo\u2028bar\r\nbaz\f"))
+	assert.Equal(t, []string{"\x1b[31mfoo", "bar\x1b[0m"}, splitMandatoryBreaks("\x1b[31mfoo\vbar\x1b[0m"))
+	assert.Equal(t, []string{"\u200b", "foo"}, splitMandatoryBreaks("\u200b\r\nfoo"))
+	assert.Equal(t, []string{"a\t", "b"}, splitMandatoryBreaks("a\t\r\nb"))
+}
+
+func TestSegmentLineUnicode(t *testing.T) {
+	cases := []struct {
+		Input  string
+		Output []string
+	}{
+		{
+			"This is an example.",
+			[]string{"This", " ", "is", " ", "an", " ", "example."},
+		},
+		// break after hyphens, slashes and dashes
+		{
+			"foo-bar/baz—qux",
+			[]string{"foo-", "bar/", "baz", "—", "qux"},
+		},
+		// no break before closing punctuation, nor after opening punctuation
+		{
+			"see ( the docs ) now !",
+			[]string{"see", " ", "( the", " ", "docs )", " ", "now !"},
+		},
+		// non-breaking space, word joiner and zero width space
+		{
+			"a\u00a0b c\u2060d e\u200bf",
+			[]string{"a\u00a0b", " ", "c\u2060d", " ", "e\u200b", "f"},
+		},
+		{
+			"  leading  spaces  ",
+			[]string{"  ", "leading", "  ", "spaces", "  "},
+		},
+		// CJK: no break before full stop and small kana
+		{
+			"一只懒狗。ちょっと",
+			[]string{"一", "只", "懒", "狗。", "ちょっ", "と"},
+		},
+	}
+
+	for i, tc := range cases {
+		chunks := segmentLineUnicode(tc.Input)
+		if !reflect.DeepEqual(chunks, tc.Output) {
+			t.Fatalf("Case %d Input:\n\n`%s`\n\nExpected Output:\n\n`[%s]`\n\nActual Output:\n\n`[%s]`\n\n",
+				i, tc.Input, strings.Join(tc.Output, ", "), strings.Join(chunks, ", "))
+		}
+	}
+}
+
+func BenchmarkSegmentLineUnicode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		segmentLineUnicode("This is a 'complex' example, where   一只 and English 混合了。")
+	}
+}
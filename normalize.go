@@ -0,0 +1,50 @@
+package text
+
+import (
+	"strings"
+)
+
+// Normalize simplify the SGR and OSC 8 hyperlink sequences of a string. The
+// string is replayed through an EscapeState, and the minimal sequences are
+// emitted only before the visible characters and the line breaks, so that
+// redundant sequences and styles applied to zero-width content are removed.
+// A style change or a reset doesn't move across a line break, to keep each
+// line self-contained.
+// The visible text is left identical, and so is the escape state at the end
+// of the string. Other escape sequences are kept in place.
+func Normalize(s string) string {
+	var out strings.Builder
+
+	// emitted is the state of the output so far, and wanted the state
+	// requested by the input
+	var emitted, wanted EscapeState
+
+	walkGraphemes(s, func(str string, escape bool, width int) bool {
+		switch {
+		case escape:
+			if _, ok := sgrParams(str); ok {
+				wanted.Witness(str)
+				return true
+			}
+			if _, ok := parseHyperlink(str); ok {
+				wanted.Witness(str)
+				return true
+			}
+			// other sequences may depend on the state, like erasing with
+			// the background color
+			out.WriteString(emitted.TransitionString(&wanted))
+			emitted = wanted
+
+		case width > 0 || strings.HasSuffix(str, "\n"):
+			out.WriteString(emitted.TransitionString(&wanted))
+			emitted = wanted
+		}
+
+		out.WriteString(str)
+		return true
+	})
+
+	out.WriteString(emitted.TransitionString(&wanted))
+
+	return out.String()
+}
@@ -0,0 +1,45 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		input, output string
+	}{
+		{"", ""},
+		{"foo", "foo"},
+		{"\x1b[31mfoo\x1b[0m", "\x1b[31mfoo\x1b[0m"},
+		// redundant sequences
+		{"\x1b[31m\x1b[0m\x1b[31m\x1b[1mfoo", "\x1b[1;31mfoo"},
+		{"\x1b[31mfoo\x1b[31mbar\x1b[0m", "\x1b[31mfoobar\x1b[0m"},
+		{"\x1b[1mfoo\x1b[0m\x1b[1mbar\x1b[0m", "\x1b[1mfoobar\x1b[0m"},
+		// targeted changes
+		{"\x1b[1;31mfoo\x1b[0m\x1b[1mbar\x1b[0m", "\x1b[1;31mfoo\x1b[39mbar\x1b[0m"},
+		// styles on zero-width content
+		{"foo\x1b[4m\x1b[0mbar", "foobar"},
+		{"a\x1b[31m​\x1b[0mb", "a​b"},
+		// the state doesn't move across line breaks
+		{"\x1b[31mfoo\x1b[0m\nbar", "\x1b[31mfoo\x1b[0m\nbar"},
+		{"\x1b[1;31mfoo\x1b[22m\r\nbar\x1b[0m", "\x1b[1;31mfoo\x1b[22m\r\nbar\x1b[0m"},
+		{"\x1b[44m\n\x1b[0mfoo", "\x1b[44m\n\x1b[0mfoo"},
+		// the final state is kept
+		{"foo\x1b[31m", "foo\x1b[31m"},
+		{"\x1b[31mfoo\x1b[1m\x1b[22m", "\x1b[31mfoo"},
+		// hyperlinks
+		{"\x1b]8;;http://a\x1b\\\x1b]8;;\x1b\\foo", "foo"},
+		{"\x1b]8;;http://a\x1b\\\x1b[1mfoo\x1b]8;;\x1b\\\x1b[0m", "\x1b[1m\x1b]8;;http://a\x1b\\foo\x1b[0m\x1b]8;;\x1b\\"},
+		// other sequences are kept, with the state they may depend on
+		{"\x1b[41m\x1b[2K\x1b[0mfoo", "\x1b[41m\x1b[2K\x1b[0mfoo"},
+		{"\x1b[1mfoo\x1b[2Jbar", "\x1b[1mfoo\x1b[2Jbar"},
+		// wide characters
+		{"\x1b[32m一只\x1b[32m狐狸\x1b[0m", "\x1b[32m一只狐狸\x1b[0m"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, Normalize(c.input), "input %q", c.input)
+	}
+}
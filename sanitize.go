@@ -0,0 +1,264 @@
+package text
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// SGRAttributes is a set of SGR attributes, used to select the ones kept by
+// Sanitize().
+type SGRAttributes uint
+
+const (
+	SGRBold SGRAttributes = 1 << iota
+	SGRDim
+	SGRItalic
+	// SGRUnderline include the underline style
+	SGRUnderline
+	// SGRBlink include the fast blink
+	SGRBlink
+	SGRReverse
+	SGRHidden
+	SGRCrossedOut
+	SGROverline
+	// SGRFrame include the framed and encircled attributes
+	SGRFrame
+	// SGRScript include the superscript and subscript attributes
+	SGRScript
+	SGRForeground
+	SGRBackground
+	SGRUnderlineColor
+
+	SGRNone SGRAttributes = 0
+	SGRAll                = SGRBold | SGRDim | SGRItalic | SGRUnderline | SGRBlink | SGRReverse |
+		SGRHidden | SGRCrossedOut | SGROverline | SGRFrame | SGRScript |
+		SGRForeground | SGRBackground | SGRUnderlineColor
+)
+
+type sanitizeOpts struct {
+	sgr            SGRAttributes
+	hyperlinks     bool
+	schemes        []string
+	removeControls bool
+}
+
+// SanitizeOption is a functional option for the Sanitize() function
+type SanitizeOption func(opts *sanitizeOpts)
+
+// SanitizeSGR configure the SGR attributes kept by Sanitize(). The default is SGRAll.
+func SanitizeSGR(attributes SGRAttributes) SanitizeOption {
+	return func(opts *sanitizeOpts) {
+		opts.sgr = attributes
+	}
+}
+
+// SanitizeHyperlinks allow the OSC 8 hyperlinks with one of the given URL
+// schemes, like "https", or any scheme if none is given. By default, the
+// hyperlinks are removed, but not their label.
+func SanitizeHyperlinks(schemes ...string) SanitizeOption {
+	return func(opts *sanitizeOpts) {
+		opts.hyperlinks = true
+		opts.schemes = schemes
+	}
+}
+
+// SanitizeRemoveControls make Sanitize() remove the control characters,
+// instead of replacing them with a visible placeholder.
+func SanitizeRemoveControls() SanitizeOption {
+	return func(opts *sanitizeOpts) {
+		opts.removeControls = true
+	}
+}
+
+// Sanitize make an untrusted text safe to display in a terminal:
+//   - the SGR sequences are kept, limited to the allowed attributes (see SanitizeSGR)
+//   - the hyperlinks are removed, unless allowed (see SanitizeHyperlinks)
+//   - all the other escape sequences are removed, like cursor movements, screen
+//     clearing, window title changes or clipboard access
+//   - the line breaks "\r\n" are normalized to "\n"
+//   - the C0 and C1 control characters, except the line breaks and the tabs, are
+//     replaced with a visible placeholder, or removed (see SanitizeRemoveControls).
+//     The C0 controls and DEL are replaced with their symbol from the Control
+//     Pictures block, like ␍ for CR. A C1 control is replaced with its 7-bit
+//     form, the symbol of ESC followed by a character, like ␛E for NEL.
+//   - the invalid UTF-8 is replaced with U+FFFD
+//   - the escape state is reset at the end, so that the text doesn't affect
+//     what follows
+func Sanitize(s string, opts ...SanitizeOption) string {
+	sanitizeOpts := &sanitizeOpts{sgr: SGRAll}
+	for _, opt := range opts {
+		opt(sanitizeOpts)
+	}
+
+	var out strings.Builder
+	var input, emitted EscapeState
+
+	walkGraphemes(s, func(str string, escape bool, _ int) bool {
+		if !escape {
+			sanitizeOpts.writeText(&out, str)
+			return true
+		}
+
+		_, isSGR := sgrParams(str)
+		link, isLink := parseHyperlink(str)
+		kind, _ := parseEscape(str)
+
+		switch {
+		case isSGR:
+			input.Witness(str)
+		case isLink:
+			input.Link = link
+		case kind == escC1 || str == string(Escape):
+			// a lone control, not an escape sequence
+			sanitizeOpts.writeText(&out, str)
+			return true
+		default:
+			return true
+		}
+
+		allowed := sanitizeOpts.filter(input)
+		out.WriteString(emitted.TransitionString(&allowed))
+		emitted = allowed
+		return true
+	})
+
+	out.WriteString(emitted.ResetString())
+
+	return out.String()
+}
+
+// writeText write a piece of text without escape sequence, with the control
+// characters and the invalid UTF-8 replaced, and "\r\n" normalized to "\n".
+func (opts *sanitizeOpts) writeText(out *strings.Builder, s string) {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			out.WriteRune(utf8.RuneError)
+		case r == '\n' || r == '\t':
+			out.WriteRune(r)
+		case r == '\r' && strings.HasPrefix(s[i:], "\n"):
+			// line break, written as "\n"
+		case r < 0x20:
+			if !opts.removeControls {
+				// control pictures, like ␛ for ESC
+				out.WriteRune(0x2400 + r)
+			}
+		case r == 0x7f:
+			if !opts.removeControls {
+				out.WriteRune('␡')
+			}
+		case r >= 0x80 && r <= 0x9f:
+			if !opts.removeControls {
+				// 7-bit form, like ␛[ for CSI
+				out.WriteRune('␛')
+				out.WriteRune(r - 0x40)
+			}
+		default:
+			out.WriteRune(r)
+		}
+	}
+}
+
+// filter return the given state limited to the allowed attributes and hyperlinks.
+func (opts *sanitizeOpts) filter(es EscapeState) EscapeState {
+	var result EscapeState
+	sgr := opts.sgr
+
+	if sgr&SGRBold != 0 {
+		result.Bold = es.Bold
+	}
+	if sgr&SGRDim != 0 {
+		result.Dim = es.Dim
+	}
+	if sgr&SGRItalic != 0 {
+		result.Italic = es.Italic
+	}
+	if sgr&SGRUnderline != 0 {
+		result.Underlined = es.Underlined
+		result.UnderlineStyle = es.UnderlineStyle
+	}
+	if sgr&SGRBlink != 0 {
+		result.Blink = es.Blink
+		result.FastBlink = es.FastBlink
+	}
+	if sgr&SGRReverse != 0 {
+		result.Reverse = es.Reverse
+	}
+	if sgr&SGRHidden != 0 {
+		result.Hidden = es.Hidden
+	}
+	if sgr&SGRCrossedOut != 0 {
+		result.CrossedOut = es.CrossedOut
+	}
+	if sgr&SGROverline != 0 {
+		result.Overlined = es.Overlined
+	}
+	if sgr&SGRFrame != 0 {
+		result.Framed = es.Framed
+		result.Encircled = es.Encircled
+	}
+	if sgr&SGRScript != 0 {
+		result.Superscript = es.Superscript
+		result.Subscript = es.Subscript
+	}
+	if sgr&SGRForeground != 0 {
+		result.FgColor = es.FgColor
+	}
+	if sgr&SGRBackground != 0 {
+		result.BgColor = es.BgColor
+	}
+	if sgr&SGRUnderlineColor != 0 {
+		result.UnderlineColor = es.UnderlineColor
+	}
+
+	if opts.allowLink(es.Link) {
+		result.Link = es.Link
+	}
+
+	return result
+}
+
+// allowLink return true if the given hyperlink can be kept.
+func (opts *sanitizeOpts) allowLink(link Hyperlink) bool {
+	if !opts.hyperlinks || link.IsZero() {
+		return false
+	}
+
+	// the link is written back as is, it must not contain controls
+	for _, r := range link.Params + link.URL {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return false
+		}
+	}
+
+	if len(opts.schemes) == 0 {
+		return true
+	}
+	u, err := url.Parse(link.URL)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range opts.schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// StripEscapes remove all the terminal escape sequences of a string, including
+// the SGR sequences and the hyperlinks. The text is left untouched.
+func StripEscapes(s string) string {
+	var out strings.Builder
+	walkGraphemes(s, func(str string, escape bool, _ int) bool {
+		if !escape {
+			out.WriteString(str)
+		}
+		return true
+	})
+	return out.String()
+}
@@ -0,0 +1,68 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	cases := []struct {
+		input  string
+		opts   []SanitizeOption
+		output string
+	}{
+		{"", nil, ""},
+		{"foo\n\tbar 一只", nil, "foo\n\tbar 一只"},
+		// SGR sequences are kept
+		{"\x1b[1;31mfoo\x1b[0m bar", nil, "\x1b[1;31mfoo\x1b[0m bar"},
+		// the state is reset at the end
+		{"\x1b[4mfoo", nil, "\x1b[4mfoo\x1b[0m"},
+		// dangerous sequences are removed
+		{"foo\x1b[2J\x1b[1;1Hbar", nil, "foobar"},
+		{"\x1b]0;title\x07foo", nil, "foo"},
+		{"\x1b]52;c;ZXZpbA==\x1b\\foo", nil, "foo"},
+		{"\x1bPqpayload\x1b\\foo\x1b7", nil, "foo"},
+		{"\u009b2Jfoo", nil, "foo"},
+		// controls are replaced
+		{"foo\rbar\x07\x7f", nil, "foo␍bar␇␡"},
+		{"foo\u0085bar\x1b", nil, "foo␛Ebar␛"},
+		{"\u0084foo\u008d", nil, "␛Dfoo␛M"},
+		{"foo\xffbar", nil, "foo�bar"},
+		// CR LF is a line break
+		{"hello\r\nworld\r\n", nil, "hello\nworld\n"},
+		{"\u200b\r\n\r\r\n", nil, "\u200b\n␍\n"},
+		{"foo\r\nbar\x00", []SanitizeOption{SanitizeRemoveControls()}, "foo\nbar"},
+		// allow-list of attributes
+		{"\x1b[1;5;31;44mfoo\x1b[0m", []SanitizeOption{SanitizeSGR(SGRBold | SGRForeground)}, "\x1b[1;31mfoo\x1b[0m"},
+		{"\x1b[5mfoo\x1b[0m", []SanitizeOption{SanitizeSGR(SGRAll &^ SGRBlink)}, "foo"},
+		{"\x1b[1mfoo\x1b[0m", []SanitizeOption{SanitizeSGR(SGRNone)}, "foo"},
+		// hyperlinks
+		{"\x1b]8;;https://example.com\x1b\\foo\x1b]8;;\x1b\\", nil, "foo"},
+		{"\x1b]8;;https://example.com\x1b\\foo\x1b]8;;\x1b\\", []SanitizeOption{SanitizeHyperlinks()},
+			"\x1b]8;;https://example.com\x1b\\foo\x1b]8;;\x1b\\"},
+		{"\x1b]8;;https://example.com\x1b\\foo", []SanitizeOption{SanitizeHyperlinks("http", "https")},
+			"\x1b]8;;https://example.com\x1b\\foo\x1b]8;;\x1b\\"},
+		{"\x1b]8;;file:///etc/passwd\x1b\\foo\x1b]8;;\x1b\\", []SanitizeOption{SanitizeHyperlinks("https")}, "foo"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, Sanitize(c.input, c.opts...), "input %q", c.input)
+	}
+}
+
+func TestStripEscapes(t *testing.T) {
+	cases := []struct {
+		input, output string
+	}{
+		{"", ""},
+		{"foo", "foo"},
+		{"\x1b[1;31mfoo\x1b[0m\x1b[2Kbar", "foobar"},
+		{"\x1b]8;;https://example.com\x1b\\一只\x1b]8;;\x1b\\", "一只"},
+		{"foo\x1b]0;title", "foo"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.output, StripEscapes(c.input), "input %q", c.input)
+	}
+}
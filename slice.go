@@ -0,0 +1,115 @@
+package text
+
+import (
+	"strings"
+)
+
+type sliceOpts struct {
+	filler   string
+	tabWidth int
+}
+
+// SliceOption is a functional option for the Slice() function
+type SliceOption func(opts *sliceOpts)
+
+// SliceFiller configure the string written in place of each cell of a wide
+// character crossing one of the boundaries of the slice. It should be one
+// cell wide. The default is a space, so that the slice has the exact
+// requested width. An empty filler leave these cells out.
+func SliceFiller(filler string) SliceOption {
+	return func(opts *sliceOpts) {
+		opts.filler = filler
+	}
+}
+
+// SliceTabWidth configure the distance between two tab stops. The default is
+// TabWidth.
+func SliceTabWidth(tabWidth int) SliceOption {
+	return func(opts *sliceOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+// Slice return the part of a line between the cell columns startCol (included)
+// and endCol (excluded), for example to scroll horizontally.
+// Handle properly terminal color escape code: the style active at startCol is
+// reopened at the beginning of the slice, and closed at the end, so that the
+// slice render the same in isolation. The escape sequences before startCol
+// other than the SGR and hyperlink ones are removed, as well as all the escape
+// sequences after endCol.
+// A wide character crossing a boundary is replaced with a filler (see
+// SliceFiller), with the same style.
+// Tabs are expanded into spaces (see SliceTabWidth).
+//
+// Required: The line shall not contain "\n"
+func Slice(line string, startCol, endCol int, opts ...SliceOption) string {
+	sliceOpts := &sliceOpts{filler: " ", tabWidth: TabWidth}
+	for _, opt := range opts {
+		opt(sliceOpts)
+	}
+
+	if startCol < 0 {
+		startCol = 0
+	}
+	if endCol <= startCol {
+		return ""
+	}
+
+	var out strings.Builder
+	var state EscapeState
+	started := false
+
+	// start reopen the style active at the beginning of the slice
+	start := func() {
+		if !started {
+			out.WriteString(state.FormatString())
+			started = true
+		}
+	}
+
+	col := 0
+	walkGraphemes(expandTabs(line, 0, sliceOpts.tabWidth), func(str string, escape bool, width int) bool {
+		if col >= endCol {
+			return false
+		}
+
+		if escape {
+			_, isSGR := sgrParams(str)
+			_, isLink := parseHyperlink(str)
+			if col >= startCol && (started || !(isSGR || isLink)) {
+				start()
+				out.WriteString(str)
+			}
+			// otherwise the style is reopened once, before the slice content
+			state.Witness(str)
+			return true
+		}
+
+		switch {
+		case col >= startCol && col+width <= endCol:
+			start()
+			out.WriteString(str)
+		case col < startCol && col+width > startCol:
+			// wide character crossing the start
+			start()
+			cells := col + width - startCol
+			if cells > endCol-startCol {
+				cells = endCol - startCol
+			}
+			out.WriteString(strings.Repeat(sliceOpts.filler, cells))
+		case col >= startCol:
+			// wide character crossing the end
+			start()
+			out.WriteString(strings.Repeat(sliceOpts.filler, endCol-col))
+		}
+
+		col += width
+		return true
+	})
+
+	if started {
+		out.WriteString(state.ResetString())
+	}
+
+	return out.String()
+}
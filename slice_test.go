@@ -0,0 +1,49 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlice(t *testing.T) {
+	cases := []struct {
+		line       string
+		start, end int
+		opts       []SliceOption
+		output     string
+	}{
+		{"", 0, 10, nil, ""},
+		{"foobar", 0, 0, nil, ""},
+		{"foobar", 4, 2, nil, ""},
+		{"foobar", 2, 4, nil, "ob"},
+		{"foobar", -2, 3, nil, "foo"},
+		{"foobar", 4, 10, nil, "ar"},
+		{"foobar", 6, 10, nil, ""},
+		// the style is carried into the slice, and closed at the end
+		{"\x1b[31mfoo\x1b[1mbar\x1b[0m baz", 1, 5, nil, "\x1b[31moo\x1b[1mba\x1b[0m"},
+		{"\x1b[31mfoo\x1b[0m bar", 4, 7, nil, "bar"},
+		{"\x1b[31mfoo\x1b[0m bar", 3, 7, nil, " bar"},
+		{"\x1b[31mfoo\x1b[0m\x1b[1m bar", 3, 7, nil, "\x1b[1m bar\x1b[0m"},
+		{"\x1b[31mfoo\x1b[0m bar", 0, 2, nil, "\x1b[31mfo\x1b[0m"},
+		{"see \x1b]8;;https://example.com\x1b\\the docs\x1b]8;;\x1b\\", 6, 9, nil,
+			"\x1b]8;;https://example.com\x1b\\e d\x1b]8;;\x1b\\"},
+		// other sequences are only kept in the slice
+		{"a\x1b[2Kbc\x1b[1Kd", 2, 3, nil, "c"},
+		{"a\x1b[2Kbc\x1b[1Kd", 1, 3, nil, "\x1b[2Kbc"},
+		// wide characters crossing a boundary
+		{"一只狐狸", 1, 6, nil, " 只狐"},
+		{"一只狐狸", 1, 7, nil, " 只狐 "},
+		{"一只狐狸", 2, 7, nil, "只狐 "},
+		{"一只狐狸", 1, 2, nil, " "},
+		{"一\x1b[32m只狐狸", 3, 6, []SliceOption{SliceFiller("…")}, "\x1b[32m…狐\x1b[0m"},
+		{"一只狐狸", 1, 6, []SliceOption{SliceFiller("")}, "只狐"},
+		// tabs are expanded
+		{"a\tb", 2, 5, nil, "  b"},
+	}
+
+	for _, c := range cases {
+		output := Slice(c.line, c.start, c.end, c.opts...)
+		assert.Equal(t, c.output, output, "line %q from %d to %d", c.line, c.start, c.end)
+	}
+}
@@ -0,0 +1,220 @@
+package text
+
+import (
+	"strings"
+)
+
+// StyledRun is a piece of text with a single style. The text doesn't contain
+// any escape sequence.
+type StyledRun struct {
+	Text  string
+	Style EscapeState
+}
+
+// StyledText is a text made of runs, each with its own style, as an
+// alternative to manipulating the escape sequences in a string. The zero value
+// is an empty text.
+//
+// The operations never modify a StyledText in place, they return a new one.
+type StyledText struct {
+	Runs []StyledRun
+}
+
+// ParseStyledText split a string styled with SGR and OSC 8 hyperlink sequences
+// into runs. Other escape sequences are removed, the control characters and
+// the line breaks are kept in the text.
+func ParseStyledText(s string) StyledText {
+	var b styledTextBuilder
+	var state EscapeState
+
+	walkGraphemes(s, func(str string, escape bool, _ int) bool {
+		if escape {
+			state.Witness(str)
+		} else {
+			b.write(str, state)
+		}
+		return true
+	})
+
+	return b.build()
+}
+
+// String render the text back as a string with escape sequences. The
+// sequences are minimal, and the style is reset at the end.
+func (st StyledText) String() string {
+	var out strings.Builder
+	var emitted EscapeState
+
+	for _, run := range st.Runs {
+		if run.Text == "" {
+			continue
+		}
+		out.WriteString(emitted.TransitionString(&run.Style))
+		out.WriteString(run.Text)
+		emitted = run.Style
+	}
+	out.WriteString(emitted.ResetString())
+
+	return out.String()
+}
+
+// Text return the text without any style.
+func (st StyledText) Text() string {
+	var out strings.Builder
+	for _, run := range st.Runs {
+		out.WriteString(run.Text)
+	}
+	return out.String()
+}
+
+// Width return the width in a terminal of the widest line of the text. Tabs
+// advance to the next tab stop (see TabSize).
+func (st StyledText) Width(opts ...TabOption) int {
+	width := 0
+	st.walkColumns(func(_ string, col, w int, _ EscapeState) {
+		if col+w > width {
+			width = col + w
+		}
+	}, opts)
+	return width
+}
+
+// Concat return the concatenation of this text with the given ones.
+func (st StyledText) Concat(others ...StyledText) StyledText {
+	var b styledTextBuilder
+	for _, run := range st.Runs {
+		b.write(run.Text, run.Style)
+	}
+	for _, other := range others {
+		for _, run := range other.Runs {
+			b.write(run.Text, run.Style)
+		}
+	}
+	return b.build()
+}
+
+// Slice return the part of the text between the cell columns start (included)
+// and end (excluded). A wide character crossing one of the boundaries is left
+// out. The text is expected to be a single line. Tabs advance to the next tab
+// stop (see TabSize).
+func (st StyledText) Slice(start, end int, opts ...TabOption) StyledText {
+	var b styledTextBuilder
+	st.walkColumns(func(str string, col, width int, style EscapeState) {
+		if inColumns(col, width, start, end) {
+			b.write(str, style)
+		}
+	}, opts)
+	return b.build()
+}
+
+// ApplyStyle return a copy of the text with the style of the cell columns
+// between start (included) and end (excluded) modified by the given function.
+// A wide character crossing one of the boundaries is left untouched. With
+// multiple lines, the columns apply to each line. Tabs advance to the next
+// tab stop (see TabSize).
+//
+// For example, to make the first 5 columns bold:
+//
+//	st.ApplyStyle(0, 5, func(style *EscapeState) { style.Bold = true })
+func (st StyledText) ApplyStyle(start, end int, apply func(style *EscapeState), opts ...TabOption) StyledText {
+	var b styledTextBuilder
+	st.walkColumns(func(str string, col, width int, style EscapeState) {
+		if inColumns(col, width, start, end) {
+			apply(&style)
+		}
+		b.write(str, style)
+	}, opts)
+	return b.build()
+}
+
+// WalkGraphemes call fn for each extended grapheme cluster of the text in
+// order, with its width in a terminal and its style. Like with Len(), a tab
+// advances to the next tab stop (see TabSize).
+// The clusters are found over the whole text, so a cluster crossing a style
+// change, like a letter and a combining mark with different styles, is kept
+// whole with the style of its start.
+// The iteration stops as soon as fn returns false.
+func (st StyledText) WalkGraphemes(fn func(grapheme string, width int, style EscapeState) bool, opts ...TabOption) {
+	tabWidth := tabWidth(opts)
+	col := 0
+
+	// run is the index of the run containing pos, ending at runEnd
+	run, runEnd := -1, 0
+	pos := 0
+
+	walkGraphemes(st.Text(), func(str string, _ bool, width int) bool {
+		for pos >= runEnd {
+			run++
+			runEnd += len(st.Runs[run].Text)
+		}
+		pos += len(str)
+
+		if str == "\t" {
+			width = tabStopWidth(col, tabWidth)
+		}
+		col += width
+		if strings.HasSuffix(str, "\n") {
+			col = 0
+		}
+		return fn(str, width, st.Runs[run].Style)
+	})
+}
+
+// walkColumns call fn for each grapheme with the cell column where it starts.
+// The column restart at zero after each line break.
+func (st StyledText) walkColumns(fn func(str string, col, width int, style EscapeState), opts []TabOption) {
+	col := 0
+	st.WalkGraphemes(func(str string, width int, style EscapeState) bool {
+		fn(str, col, width, style)
+		col += width
+		if strings.HasSuffix(str, "\n") {
+			col = 0
+		}
+		return true
+	}, opts...)
+}
+
+// styledTextBuilder build a StyledText piece by piece, merging the
+// consecutive pieces with the same style in a single run.
+type styledTextBuilder struct {
+	runs  []StyledRun
+	text  strings.Builder
+	style EscapeState
+}
+
+// write add a piece of text with the given style at the end.
+func (b *styledTextBuilder) write(text string, style EscapeState) {
+	if text == "" {
+		return
+	}
+	if b.text.Len() > 0 && !styleEqual(&b.style, &style) {
+		b.flush()
+	}
+	b.style = style
+	b.text.WriteString(text)
+}
+
+func (b *styledTextBuilder) flush() {
+	if b.text.Len() > 0 {
+		b.runs = append(b.runs, StyledRun{Text: b.text.String(), Style: b.style})
+		b.text.Reset()
+	}
+}
+
+// build return the StyledText written so far.
+func (b *styledTextBuilder) build() StyledText {
+	b.flush()
+	return StyledText{Runs: b.runs}
+}
+
+// inColumns return true if a grapheme is fully within the cell columns between
+// start (included) and end (excluded). A zero-width grapheme is included only
+// if it's strictly before end.
+func inColumns(col, width, start, end int) bool {
+	return col >= start && col < end && col+width <= end
+}
+
+// styleEqual return true if both states render the same.
+func styleEqual(a, b *EscapeState) bool {
+	return a.FormatString() == b.FormatString()
+}
@@ -0,0 +1,138 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStyledText(t *testing.T) {
+	bold := EscapeState{Bold: true}
+	red := EscapeState{FgColor: ColorIndex(31)}
+	boldRed := EscapeState{Bold: true, FgColor: ColorIndex(31)}
+	link := EscapeState{Link: Hyperlink{URL: "http://a"}}
+
+	cases := []struct {
+		input  string
+		runs   []StyledRun
+		output string
+	}{
+		{"", nil, ""},
+		{"foo", []StyledRun{{"foo", EscapeState{}}}, "foo"},
+		{"\x1b[1mfoo\x1b[31mbar\x1b[0m baz", []StyledRun{
+			{"foo", bold}, {"bar", boldRed}, {" baz", EscapeState{}},
+		}, "\x1b[1mfoo\x1b[31mbar\x1b[0m baz"},
+		// redundant sequences are merged, other sequences removed
+		{"\x1b[31mfoo\x1b[31m\x1b[2Kbar\n", []StyledRun{{"foo" + "bar\n", red}}, "\x1b[31mfoobar\n\x1b[0m"},
+		{"\x1b]8;;http://a\x1b\\foo\x1b]8;;\x1b\\", []StyledRun{{"foo", link}}, "\x1b]8;;http://a\x1b\\foo\x1b]8;;\x1b\\"},
+	}
+
+	for _, c := range cases {
+		st := ParseStyledText(c.input)
+		assert.Equal(t, c.runs, st.Runs, "input %q", c.input)
+		assert.Equal(t, c.output, st.String(), "input %q", c.input)
+	}
+}
+
+func TestStyledTextText(t *testing.T) {
+	st := ParseStyledText("\x1b[1mfoo\x1b[0m 一只\nbar")
+	assert.Equal(t, "foo 一只\nbar", st.Text())
+	assert.Equal(t, 8, st.Width())
+
+	// tabs advance to the next tab stop, like with Len()
+	st = ParseStyledText("a\tb\x1b[1m\tc")
+	assert.Equal(t, Len("a\tb\tc"), st.Width())
+	assert.Equal(t, "\x1b[1m\tc\x1b[0m", st.Slice(5, 9).String())
+}
+
+func TestStyledTextConcat(t *testing.T) {
+	st := ParseStyledText("\x1b[1mfoo").Concat(ParseStyledText("\x1b[1mbar"), ParseStyledText("baz"))
+	assert.Equal(t, []StyledRun{{"foobar", EscapeState{Bold: true}}, {"baz", EscapeState{}}}, st.Runs)
+	assert.Equal(t, StyledText{}, StyledText{}.Concat())
+}
+
+func TestStyledTextSlice(t *testing.T) {
+	cases := []struct {
+		input      string
+		start, end int
+		output     string
+	}{
+		{"foo", 0, 0, ""},
+		{"foo", 0, 10, "foo"},
+		{"\x1b[1mfoo\x1b[31mbar\x1b[0m", 2, 4, "\x1b[1mo\x1b[31mb\x1b[0m"},
+		// wide characters crossing a boundary are left out
+		{"一只狐狸", 1, 6, "只狐"},
+		{"一只狐狸", 2, 7, "只狐"},
+		// zero-width graphemes
+		{"a​b", 1, 2, "​b"},
+		{"a​b", 0, 1, "a"},
+	}
+
+	for _, c := range cases {
+		output := ParseStyledText(c.input).Slice(c.start, c.end).String()
+		assert.Equal(t, c.output, output, "input %q from %d to %d", c.input, c.start, c.end)
+	}
+}
+
+func TestStyledTextApplyStyle(t *testing.T) {
+	bold := func(style *EscapeState) { style.Bold = true }
+
+	cases := []struct {
+		input      string
+		start, end int
+		output     string
+	}{
+		{"foobar", 2, 4, "fo\x1b[1mob\x1b[0mar"},
+		{"\x1b[31mfoobar", 0, 3, "\x1b[1;31mfoo\x1b[22mbar\x1b[0m"},
+		{"一只狐狸", 1, 5, "一\x1b[1m只\x1b[0m狐狸"},
+		// each line
+		{"foo\nbar", 1, 2, "f\x1b[1mo\x1b[0mo\nb\x1b[1ma\x1b[0mr"},
+	}
+
+	for _, c := range cases {
+		output := ParseStyledText(c.input).ApplyStyle(c.start, c.end, bold).String()
+		assert.Equal(t, c.output, output, "input %q from %d to %d", c.input, c.start, c.end)
+	}
+}
+
+func TestStyledTextWalkGraphemes(t *testing.T) {
+	var graphemes []string
+	var widths []int
+	var bolds []bool
+	ParseStyledText("a\x1b[1m一e\u0301").WalkGraphemes(func(grapheme string, width int, style EscapeState) bool {
+		graphemes = append(graphemes, grapheme)
+		widths = append(widths, width)
+		bolds = append(bolds, style.Bold)
+		return true
+	})
+	assert.Equal(t, []string{"a", "一", "e\u0301"}, graphemes)
+	assert.Equal(t, []int{1, 2, 1}, widths)
+	assert.Equal(t, []bool{false, true, true}, bolds)
+
+	// a cluster crossing a style change is kept whole
+	graphemes, bolds = nil, nil
+	ParseStyledText("ae\x1b[1m\u0301b").WalkGraphemes(func(grapheme string, _ int, style EscapeState) bool {
+		graphemes = append(graphemes, grapheme)
+		bolds = append(bolds, style.Bold)
+		return true
+	})
+	assert.Equal(t, []string{"a", "e\u0301", "b"}, graphemes)
+	assert.Equal(t, []bool{false, false, true}, bolds)
+
+	// tabs advance to the next tab stop
+	widths = nil
+	ParseStyledText("a\tb\x1b[1m\tc").WalkGraphemes(func(_ string, width int, _ EscapeState) bool {
+		widths = append(widths, width)
+		return true
+	}, TabSize(8))
+	assert.Equal(t, []int{1, 7, 1, 7, 1}, widths)
+	assert.Equal(t, 17, ParseStyledText("a\tb\x1b[1m\tc").Width(TabSize(8)))
+
+	// stop early
+	count := 0
+	ParseStyledText("a\x1b[1mbc").WalkGraphemes(func(string, int, EscapeState) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
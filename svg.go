@@ -0,0 +1,290 @@
+package text
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type svgOpts struct {
+	fontFamily string
+	fontSize   float64
+	cellWidth  float64
+	cellHeight float64
+	padding    float64
+	fg, bg     string
+	palette    [16]string
+	tabWidth   int
+}
+
+// SVGOption is a functional option for the ToSVG() function
+type SVGOption func(opts *svgOpts)
+
+// SVGFont configure the font family and the font size in pixels. The font
+// should be monospace. The default is the generic "monospace" family at 14px.
+func SVGFont(family string, size float64) SVGOption {
+	return func(opts *svgOpts) {
+		opts.fontFamily = family
+		opts.fontSize = size
+	}
+}
+
+// SVGCellSize configure the size in pixels of a cell of the grid. The default
+// is derived from the font size: 0.6 times its size in width, which match most
+// monospace fonts, and 1.2 times its size in height.
+func SVGCellSize(width, height float64) SVGOption {
+	return func(opts *svgOpts) {
+		opts.cellWidth = width
+		opts.cellHeight = height
+	}
+}
+
+// SVGPadding configure the margin in pixels around the text. The default is 10px.
+func SVGPadding(padding float64) SVGOption {
+	return func(opts *svgOpts) {
+		opts.padding = padding
+	}
+}
+
+// SVGColors configure the default foreground and background colors, as SVG
+// colors. The default is the basic colors 7 and 0 of the palette.
+func SVGColors(fg, bg string) SVGOption {
+	return func(opts *svgOpts) {
+		opts.fg = fg
+		opts.bg = bg
+	}
+}
+
+// SVGPalette configure the SVG colors used for the 16 basic colors, the 8
+// normal ones followed by the 8 bright ones. The default is the xterm palette.
+func SVGPalette(palette [16]string) SVGOption {
+	return func(opts *svgOpts) {
+		opts.palette = palette
+	}
+}
+
+// SVGTabWidth configure the distance between two tab stops. The default is
+// TabWidth.
+func SVGTabWidth(tabWidth int) SVGOption {
+	return func(opts *svgOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+// svgRun is a run of graphemes with the same style, drawn at the same position.
+type svgRun struct {
+	col   int
+	width int
+	state EscapeState
+	text  strings.Builder
+	// closed mean that the following graphemes need a new position
+	closed bool
+}
+
+// ToSVG render a text styled with SGR sequences as a SVG image, like a
+// screenshot of a terminal. The text is laid out on a grid of monospace
+// cells, following the widths of this package: the wide characters occupy
+// two cells, and the tabs are expanded (see SVGTabWidth). The size of the image
+// is given by the longest line.
+//
+// The colors, bold, dim, italic, underline, overline, crossed out, reverse
+// video and hidden attributes are rendered. The other attributes, the other
+// escape sequences and the control characters are ignored. A final empty line
+// is not rendered.
+func ToSVG(s string, opts ...SVGOption) string {
+	svgOpts := &svgOpts{
+		fontFamily: "monospace",
+		fontSize:   14,
+		padding:    10,
+		tabWidth:   TabWidth,
+	}
+	for i, c := range basicColors {
+		svgOpts.palette[i] = rgbCSS(c[0], c[1], c[2])
+	}
+	for _, opt := range opts {
+		opt(svgOpts)
+	}
+	if svgOpts.fg == "" {
+		svgOpts.fg = svgOpts.palette[7]
+	}
+	if svgOpts.bg == "" {
+		svgOpts.bg = svgOpts.palette[0]
+	}
+	if svgOpts.cellWidth <= 0 {
+		svgOpts.cellWidth = svgOpts.fontSize * 0.6
+	}
+	if svgOpts.cellHeight <= 0 {
+		svgOpts.cellHeight = svgOpts.fontSize * 1.2
+	}
+
+	lines := strings.Split(expandTabs(s, 0, svgOpts.tabWidth), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	// the escape state carry over from a line to the next
+	var state EscapeState
+	var body strings.Builder
+	maxCols := 0
+
+	for i, line := range lines {
+		var runs []*svgRun
+		var run *svgRun
+		col := 0
+
+		walkGraphemes(strings.TrimSuffix(line, "\r"), func(str string, escape bool, width int) bool {
+			if escape {
+				state.Witness(str)
+				return true
+			}
+			if len(str) == 1 && (str[0] < 0x20 || str[0] == 0x7f) {
+				return true
+			}
+
+			// anything else than a regular single cell grapheme is positioned
+			// on its own, as the font may not have the exact same width for it
+			irregular := width > 1
+			if run == nil || run.closed || irregular || run.state.FormatString() != state.FormatString() {
+				run = &svgRun{col: col, state: state}
+				runs = append(runs, run)
+			}
+			run.text.WriteString(str)
+			run.width += width
+			run.closed = irregular
+			col += width
+			return true
+		})
+
+		if col > maxCols {
+			maxCols = col
+		}
+		svgOpts.writeLine(&body, i, runs)
+	}
+
+	width := svgOpts.format(2*svgOpts.padding + float64(maxCols)*svgOpts.cellWidth)
+	height := svgOpts.format(2*svgOpts.padding + float64(len(lines))*svgOpts.cellHeight)
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="%s" font-size="%s">`,
+		width, height, width, height, html.EscapeString(svgOpts.fontFamily), svgOpts.format(svgOpts.fontSize)))
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf(`<rect width="100%%" height="100%%" fill="%s"/>`, html.EscapeString(svgOpts.bg)))
+	out.WriteString("\n")
+	out.WriteString(body.String())
+	out.WriteString("</svg>\n")
+
+	return out.String()
+}
+
+// writeLine write the backgrounds and the text of a line of runs.
+func (opts *svgOpts) writeLine(out *strings.Builder, line int, runs []*svgRun) {
+	top := opts.padding + float64(line)*opts.cellHeight
+
+	// backgrounds, with the adjacent ones merged
+	var rectCol, rectWidth int
+	var rectFill string
+	flushRect := func() {
+		if rectFill != "" && rectWidth > 0 {
+			out.WriteString(fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`,
+				opts.x(rectCol), opts.format(top),
+				opts.format(float64(rectWidth)*opts.cellWidth), opts.format(opts.cellHeight),
+				html.EscapeString(rectFill)))
+			out.WriteString("\n")
+		}
+	}
+	for _, run := range runs {
+		_, bg := opts.colors(&run.state)
+		if bg == rectFill && rectCol+rectWidth == run.col {
+			rectWidth += run.width
+			continue
+		}
+		flushRect()
+		rectCol, rectWidth, rectFill = run.col, run.width, bg
+	}
+	flushRect()
+
+	var text strings.Builder
+	for _, run := range runs {
+		es := &run.state
+		decorations := opts.decorations(es)
+		if es.Hidden || (strings.TrimSpace(run.text.String()) == "" && decorations == "") {
+			continue
+		}
+
+		attrs := []string{`x="` + opts.x(run.col) + `"`}
+		if fg, _ := opts.colors(es); fg != opts.fg {
+			attrs = append(attrs, `fill="`+html.EscapeString(fg)+`"`)
+		}
+		if es.Bold {
+			attrs = append(attrs, `font-weight="bold"`)
+		}
+		if es.Dim {
+			attrs = append(attrs, `fill-opacity="0.5"`)
+		}
+		if es.Italic {
+			attrs = append(attrs, `font-style="italic"`)
+		}
+		if decorations != "" {
+			attrs = append(attrs, `text-decoration="`+decorations+`"`)
+		}
+		text.WriteString("<tspan " + strings.Join(attrs, " ") + ">")
+		text.WriteString(html.EscapeString(run.text.String()))
+		text.WriteString("</tspan>")
+	}
+
+	if text.Len() > 0 {
+		// the baseline, with the font centered vertically in the cell
+		baseline := top + (opts.cellHeight-opts.fontSize)/2 + opts.fontSize*0.8
+		out.WriteString(fmt.Sprintf(`<text y="%s" fill="%s" xml:space="preserve">`,
+			opts.format(baseline), html.EscapeString(opts.fg)))
+		out.WriteString(text.String())
+		out.WriteString("</text>\n")
+	}
+}
+
+// colors return the SVG foreground and background colors of an escape state.
+// The background is empty for the default one.
+func (opts *svgOpts) colors(es *EscapeState) (fg string, bg string) {
+	fg = cssColor(es.FgColor, &opts.palette)
+	bg = cssColor(es.BgColor, &opts.palette)
+	if es.Reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = opts.bg
+		}
+		if bg == "" {
+			bg = opts.fg
+		}
+	}
+	if fg == "" {
+		fg = opts.fg
+	}
+	return fg, bg
+}
+
+// decorations return the value of the text-decoration attribute of an escape state.
+func (opts *svgOpts) decorations(es *EscapeState) string {
+	var decorations []string
+	if es.Underlined {
+		decorations = append(decorations, "underline")
+	}
+	if es.Overlined {
+		decorations = append(decorations, "overline")
+	}
+	if es.CrossedOut {
+		decorations = append(decorations, "line-through")
+	}
+	return strings.Join(decorations, " ")
+}
+
+// x return the horizontal position of a column.
+func (opts *svgOpts) x(col int) string {
+	return opts.format(opts.padding + float64(col)*opts.cellWidth)
+}
+
+// format format a length, rounded to avoid the floating point noise.
+func (opts *svgOpts) format(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,70 @@
+package text
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the generated images")
+
+func TestToSVG(t *testing.T) {
+	expected := `<svg xmlns="http://www.w3.org/2000/svg" width="45.2" height="36.8" viewBox="0 0 45.2 36.8" font-family="monospace" font-size="14">` + "\n" +
+		`<rect width="100%" height="100%" fill="#000000"/>` + "\n" +
+		`<text y="22.6" fill="#e5e5e5" xml:space="preserve"><tspan x="10">foo</tspan></text>` + "\n" +
+		"</svg>\n"
+	assert.Equal(t, expected, ToSVG("foo\n"))
+
+	cases := []struct {
+		input string
+		opts  []SVGOption
+		// part of the output
+		output string
+	}{
+		// the size is given by the longest line
+		{"foo\n\tbar\n", nil, `width="78.8" height="53.6"`},
+		{"a\x1b[1;31mb<c\x1b[0m d", nil,
+			`<tspan x="10">a</tspan><tspan x="18.4" fill="#cd0000" font-weight="bold">b&lt;c</tspan><tspan x="43.6"> d</tspan>`},
+		// the wide characters occupy two cells
+		{"一只x", nil, `<tspan x="10">一</tspan><tspan x="26.8">只</tspan><tspan x="43.6">x</tspan>`},
+		// backgrounds and reverse video
+		{"\x1b[7mab\x1b[44mc\x1b[0m", nil,
+			`<rect x="10" y="10" width="25.2" height="16.8" fill="#e5e5e5"/>` + "\n" +
+				`<text y="22.6" fill="#e5e5e5" xml:space="preserve"><tspan x="10" fill="#000000">ab</tspan><tspan x="26.8" fill="#0000ee">c</tspan></text>`},
+		{"\x1b[2;3;4;9mab", nil, `<tspan x="10" fill-opacity="0.5" font-style="italic" text-decoration="underline line-through">ab</tspan>`},
+		// the style carry over to the next line
+		{"a\x1b[32m\nb", nil, `<text y="39.4" fill="#e5e5e5" xml:space="preserve"><tspan x="10" fill="#00cd00">b</tspan></text>`},
+		// options
+		{"\x1b[31;48;5;17ma", []SVGOption{
+			SVGFont("Fira Code", 10), SVGCellSize(6, 12), SVGPadding(0),
+			SVGColors("white", "black"), SVGPalette([16]string{"", "red"}),
+		}, `<rect x="0" y="0" width="6" height="12" fill="#00005f"/>` + "\n" +
+			`<text y="9" fill="white" xml:space="preserve"><tspan x="0" fill="red">a</tspan></text>`},
+	}
+
+	for _, c := range cases {
+		assert.Contains(t, ToSVG(c.input, c.opts...), c.output, "input %q", c.input)
+	}
+
+	// hidden text is not rendered
+	assert.NotContains(t, ToSVG("\x1b[8mab"), "<text")
+}
+
+// TestToSVGExample check that img/example.svg match the output of the Readme
+// example. Run with -update to regenerate it.
+func TestToSVGExample(t *testing.T) {
+	const path = "img/example.svg"
+	svg := ToSVG(wrapExample(), SVGFont("DejaVu Sans Mono, monospace", 14))
+
+	if *update {
+		err := ioutil.WriteFile(path, []byte(svg), 0644)
+		assert.NoError(t, err)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), svg, "run the tests with -update to regenerate %s", path)
+}
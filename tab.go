@@ -0,0 +1,72 @@
+package text
+
+import "strings"
+
+// TabWidth is the default distance between two tab stops. A tab advances to
+// the next tab stop, based on its column in the line.
+// It's used by all the functions measuring or formatting text, unless another
+// tab width is given as an option (see TabSize or WrapTabWidth).
+const TabWidth = 4
+
+type tabOpts struct {
+	tabWidth int
+}
+
+// TabOption is a functional option for the functions measuring or formatting
+// text with tabs, like Len() or LineAlign().
+type TabOption func(opts *tabOpts)
+
+// TabSize configure the distance between two tab stops. A tab width of 0 or
+// less makes the tabs zero-width, and removes them when formatting text.
+// The default is TabWidth.
+func TabSize(tabWidth int) TabOption {
+	return func(opts *tabOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+// tabWidth return the tab width configured by the given options.
+func tabWidth(opts []TabOption) int {
+	tabOpts := &tabOpts{tabWidth: TabWidth}
+	for _, opt := range opts {
+		opt(tabOpts)
+	}
+	return tabOpts.tabWidth
+}
+
+// tabStopWidth return the width of a tab at the given column.
+func tabStopWidth(col int, tabWidth int) int {
+	if tabWidth <= 0 {
+		return 0
+	}
+	return tabWidth - col%tabWidth
+}
+
+// expandTabs replace the tabs with spaces up to the next tab stop, while
+// ignoring the terminal escape sequences. col is the column where s starts,
+// and is reset to 0 after each line break.
+// With a tabWidth of 0 or less, the tabs are removed.
+func expandTabs(s string, col int, tabWidth int) string {
+	if !strings.ContainsRune(s, '\t') {
+		return s
+	}
+
+	var out strings.Builder
+	walkGraphemes(s, func(str string, _ bool, width int) bool {
+		switch {
+		case str == "\t":
+			width = tabStopWidth(col, tabWidth)
+			out.WriteString(strings.Repeat(" ", width))
+		case strings.HasSuffix(str, "\n"):
+			// "\n" or "\r\n"
+			col = 0
+			out.WriteString(str)
+			return true
+		default:
+			out.WriteString(str)
+		}
+		col += width
+		return true
+	})
+	return out.String()
+}
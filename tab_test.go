@@ -0,0 +1,51 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTabs(t *testing.T) {
+	cases := []struct {
+		input    string
+		col      int
+		tabWidth int
+		output   string
+	}{
+		{"foo", 0, 4, "foo"},
+		{"\tfoo", 0, 4, "    foo"},
+		{"a\tb\tc", 0, 4, "a   b   c"},
+		{"abcd\te", 0, 4, "abcd    e"},
+		{"a\tb", 2, 4, "a b"},
+		{"a\tb", 0, 8, "a       b"},
+		{"a\tb", 0, 0, "ab"},
+		// escapes are zero-width, wide chars are two columns
+		{"\x1b[31ma\x1b[0m\tb", 0, 4, "\x1b[31ma\x1b[0m   b"},
+		{"敏\tb", 0, 4, "敏  b"},
+		// columns start over on each line
+		{"ab\tc\nd\te", 0, 4, "ab  c\nd   e"},
+		{"ab\r\n\tx", 0, 4, "ab\r\n    x"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.output, expandTabs(tc.input, tc.col, tc.tabWidth), "%q", tc.input)
+	}
+}
+
+func TestTabSize(t *testing.T) {
+	assert.Equal(t, 5, Len("a\tb"))
+	assert.Equal(t, 9, Len("a\tb", TabSize(8)))
+	assert.Equal(t, 9, MaxLineLen("a\tb\nc", TabSize(8)))
+	assert.Equal(t, "a       b", LineAlignLeft("a\tb", 20, TabSize(8)))
+	assert.Equal(t, "a       b…", TruncateMax("a\tbcd", 10, TabSize(8)))
+	assert.Equal(t, "  a     b ", LeftPadMaxLine("a\tb", 10, 2, TabSize(8)))
+
+	assert.Equal(t, 2, Len("a\tb", TabSize(0)))
+	assert.Equal(t, "ab", LineAlignLeft("a\tb", 20, TabSize(0)))
+
+	// the other functions have their own option
+	assert.Equal(t, "a       b", Truncate("a\tb", 10, TruncateTabWidth(8)))
+	assert.Equal(t, "   b", Slice("a\tb", 5, 9, SliceTabWidth(8)))
+	assert.Equal(t, "a       b", ToHTML("a\tb", HTMLTabWidth(8)))
+}
@@ -0,0 +1,248 @@
+package text
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Formatting flags of a TabWriter.
+const (
+	// TabAlignRight align the content of the cells to the right.
+	TabAlignRight uint = 1 << iota
+	// TabDiscardEmptyColumns give a zero width to the columns with only empty cells.
+	TabDiscardEmptyColumns
+	// TabDebug print a vertical bar between the columns.
+	TabDebug
+)
+
+// TabWriter is an io.Writer aligning the tab-terminated cells of consecutive
+// lines into columns, with elastic tab stops, like text/tabwriter. Unlike
+// text/tabwriter, the cells are measured like Len(), ignoring the terminal
+// escape sequences and handling the wide characters, and the tabs inside an
+// escape sequence are not cell separators.
+//
+// The text of a cell is written as is, so a style applied across the tab
+// separating two cells also applies to the padding between them.
+// Flush must be called after the last write.
+type TabWriter struct {
+	w        io.Writer
+	minWidth int
+	tabWidth int
+	padding  int
+	padChar  byte
+	flags    uint
+
+	// pending is the incomplete line of text
+	pending []byte
+	// lines is the buffered text, split in lines and cells
+	lines  [][]tabCell
+	widths []int
+	err    error
+}
+
+// tabCell is a cell of a TabWriter, with its visual width.
+type tabCell struct {
+	text  string
+	width int
+}
+
+// NewTabWriter create a TabWriter writing the aligned text into w.
+// The parameters are the same as with text/tabwriter:
+//   - minWidth is the minimal width of a cell, including the padding
+//   - tabWidth is the width of a tab, when padChar is '\t'
+//   - padding is added to the width of a cell before computing its width
+//   - padChar is the character used for padding. With '\t', the cells are
+//     left-aligned and their width is a multiple of tabWidth
+//   - flags is a combination of TabAlignRight, TabDiscardEmptyColumns and TabDebug
+func NewTabWriter(w io.Writer, minWidth, tabWidth, padding int, padChar byte, flags uint) *TabWriter {
+	if minWidth < 0 || tabWidth < 0 || padding < 0 {
+		panic("negative minWidth, tabWidth, or padding")
+	}
+	if padChar == '\t' {
+		// the tabs can't align to the right
+		flags &^= TabAlignRight
+	}
+	return &TabWriter{
+		w:        w,
+		minWidth: minWidth,
+		tabWidth: tabWidth,
+		padding:  padding,
+		padChar:  padChar,
+		flags:    flags,
+	}
+}
+
+// Write buffer the given text. The aligned text is written to the underlying
+// writer once a line without cell ends all the columns, or when Flush is called.
+func (tw *TabWriter) Write(p []byte) (int, error) {
+	if tw.err != nil {
+		return 0, tw.err
+	}
+
+	tw.pending = append(tw.pending, p...)
+
+	for {
+		i := bytes.IndexByte(tw.pending, '\n')
+		if i < 0 {
+			break
+		}
+		cells := splitCells(string(tw.pending[:i]))
+		tw.lines = append(tw.lines, cells)
+		tw.pending = tw.pending[i+1:]
+
+		if len(cells) == 1 {
+			// a line without cell ends all the columns, the previous lines
+			// can't change anymore
+			if err := tw.flushLines(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush write the buffered text, including the incomplete last line.
+// The columns are ended, the text written after is aligned independently.
+func (tw *TabWriter) Flush() error {
+	if tw.err != nil {
+		return tw.err
+	}
+	if len(tw.pending) > 0 {
+		tw.lines = append(tw.lines, splitCells(string(tw.pending)))
+		tw.pending = nil
+		return tw.flushLines(true)
+	}
+	return tw.flushLines(false)
+}
+
+// splitCells split a line into cells at the tabs outside of the escape
+// sequences. The last cell is the text after the last tab, and is not part
+// of a column.
+func splitCells(line string) []tabCell {
+	var cells []tabCell
+	var cell strings.Builder
+	width := 0
+
+	walkGraphemes(line, func(str string, escape bool, w int) bool {
+		if !escape && str == "\t" {
+			cells = append(cells, tabCell{text: cell.String(), width: width})
+			cell.Reset()
+			width = 0
+			return true
+		}
+		cell.WriteString(str)
+		width += w
+		return true
+	})
+
+	return append(cells, tabCell{text: cell.String(), width: width})
+}
+
+// flushLines format and write the buffered lines. The last one is terminated
+// by a line break unless partial is true.
+func (tw *TabWriter) flushLines(partial bool) error {
+	var out strings.Builder
+	tw.format(&out, 0, len(tw.lines))
+	text := out.String()
+	if partial {
+		text = strings.TrimSuffix(text, "\n")
+	}
+	tw.lines = tw.lines[:0]
+
+	_, err := io.WriteString(tw.w, text)
+	tw.err = err
+	return err
+}
+
+// format write the lines [line0, line1) with the column widths computed so far,
+// computing recursively the width of the next column for each block of
+// consecutive lines having a cell in it.
+func (tw *TabWriter) format(out *strings.Builder, line0, line1 int) {
+	column := len(tw.widths)
+
+	for this := line0; this < line1; this++ {
+		if column >= len(tw.lines[this])-1 {
+			continue
+		}
+
+		// this line has a cell in this column, start of a column block
+		tw.writeLines(out, line0, this)
+		line0 = this
+
+		width := tw.minWidth
+		discardable := true
+		for ; this < line1; this++ {
+			line := tw.lines[this]
+			if column >= len(line)-1 {
+				break
+			}
+			c := line[column]
+			if w := c.width + tw.padding; w > width {
+				width = w
+			}
+			if c.width > 0 {
+				discardable = false
+			}
+		}
+
+		if discardable && tw.flags&TabDiscardEmptyColumns != 0 {
+			width = 0
+		}
+		if tw.padChar == '\t' && tw.tabWidth > 0 {
+			// round up to a multiple of the tab width
+			width = (width + tw.tabWidth - 1) / tw.tabWidth * tw.tabWidth
+		}
+
+		tw.widths = append(tw.widths, width)
+		tw.format(out, line0, this)
+		tw.widths = tw.widths[:len(tw.widths)-1]
+		line0 = this
+	}
+
+	tw.writeLines(out, line0, line1)
+}
+
+// writeLines write the lines [line0, line1) with the current column widths.
+func (tw *TabWriter) writeLines(out *strings.Builder, line0, line1 int) {
+	for _, line := range tw.lines[line0:line1] {
+		for j, c := range line {
+			if j > 0 && tw.flags&TabDebug != 0 {
+				out.WriteByte('|')
+			}
+
+			if j >= len(tw.widths) {
+				// last cell, not part of a column
+				out.WriteString(c.text)
+				continue
+			}
+
+			if tw.flags&TabAlignRight != 0 {
+				tw.writePadding(out, c.width, tw.widths[j])
+				out.WriteString(c.text)
+			} else {
+				out.WriteString(c.text)
+				tw.writePadding(out, c.width, tw.widths[j])
+			}
+		}
+		out.WriteByte('\n')
+	}
+}
+
+// writePadding write the padding of a cell of the given width, up to the
+// width of its column.
+func (tw *TabWriter) writePadding(out *strings.Builder, textWidth, cellWidth int) {
+	if tw.padChar == '\t' {
+		if tw.tabWidth == 0 || cellWidth == 0 {
+			return
+		}
+		// the cell width is a multiple of the tab width
+		n := (cellWidth - textWidth + tw.tabWidth - 1) / tw.tabWidth
+		out.WriteString(strings.Repeat("\t", n))
+		return
+	}
+	if textWidth < cellWidth {
+		out.WriteString(strings.Repeat(string(tw.padChar), cellWidth-textWidth))
+	}
+}
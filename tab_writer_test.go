@@ -0,0 +1,121 @@
+package text
+
+import (
+	"bytes"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTabWriterLikeTabwriter(t *testing.T) {
+	inputs := []string{
+		"",
+		"foo",
+		"a\tb\tc\n",
+		"a\tb\tc\naaa\tbbbbb\tc\n\nx\ty\n",
+		"a\tb\nccc\tddd\teee\nf\tg\n",
+		"aaa\n\tb\tc\n\t\tdddd\ne\n",
+		"no line break\tat the end",
+		"a\tb\t\n\t\t\nccc\t\td\n",
+	}
+
+	params := []struct {
+		minWidth, tabWidth, padding int
+		padChar                     byte
+		flags, refFlags             uint
+	}{
+		{0, 8, 1, ' ', 0, 0},
+		{5, 0, 2, '.', 0, 0},
+		{0, 4, 1, ' ', TabAlignRight, tabwriter.AlignRight},
+		{0, 8, 1, '-', TabDebug, tabwriter.Debug},
+		{0, 8, 0, ' ', TabDiscardEmptyColumns, tabwriter.DiscardEmptyColumns},
+		{0, 4, 1, '\t', 0, 0},
+	}
+
+	for _, input := range inputs {
+		for _, p := range params {
+			var expected bytes.Buffer
+			ref := tabwriter.NewWriter(&expected, p.minWidth, p.tabWidth, p.padding, p.padChar, p.refFlags)
+			_, _ = ref.Write([]byte(input))
+			_ = ref.Flush()
+
+			for _, size := range []int{1, 3, len(input) + 1} {
+				var buf bytes.Buffer
+				w := NewTabWriter(&buf, p.minWidth, p.tabWidth, p.padding, p.padChar, p.flags)
+				for start := 0; start < len(input); start += size {
+					end := start + size
+					if end > len(input) {
+						end = len(input)
+					}
+					n, err := w.Write([]byte(input[start:end]))
+					assert.NoError(t, err)
+					assert.Equal(t, end-start, n)
+				}
+				assert.NoError(t, w.Flush())
+				assert.Equal(t, expected.String(), buf.String(), "input %q params %v chunk %d", input, p, size)
+			}
+		}
+	}
+}
+
+func TestTabWriter(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		flags  uint
+		output string
+	}{
+		{
+			"escape sequences",
+			"\x1b[31mred\x1b[0m\tx\nlonger\ty\n",
+			0,
+			"\x1b[31mred\x1b[0m    x\nlonger y\n",
+		},
+		{
+			"wide characters",
+			"狐狸\tx\nabcdef\ty\n",
+			0,
+			"狐狸   x\nabcdef y\n",
+		},
+		{
+			"right alignment",
+			"\x1b[1m1\x1b[0m\tone\n一百\ttwo\n",
+			TabAlignRight,
+			"    \x1b[1m1\x1b[0mone\n 一百two\n",
+		},
+		{
+			"tab in an escape sequence",
+			"\x1b]8;;http://a\tb\x1b\\link\x1b]8;;\x1b\\\tx\nfoo\ty\n",
+			0,
+			"\x1b]8;;http://a\tb\x1b\\link\x1b]8;;\x1b\\ x\nfoo  y\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewTabWriter(&buf, 0, 8, 1, ' ', c.flags)
+			_, err := w.Write([]byte(c.input))
+			assert.NoError(t, err)
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, c.output, buf.String())
+		})
+	}
+}
+
+func TestTabWriterEarlyWrite(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTabWriter(&buf, 0, 8, 1, ' ', 0)
+
+	_, _ = w.Write([]byte("a\tb\nccc\td\n"))
+	// the column may still grow
+	assert.Equal(t, "", buf.String())
+
+	_, _ = w.Write([]byte("end of the columns\n"))
+	assert.Equal(t, "a   b\nccc d\nend of the columns\n", buf.String())
+
+	_, _ = w.Write([]byte("x\ty"))
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "a   b\nccc d\nend of the columns\nx y", buf.String())
+}
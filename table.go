@@ -0,0 +1,273 @@
+package text
+
+import (
+	"strings"
+)
+
+// TableColumn configure a column of a Table.
+type TableColumn struct {
+	Header string
+	Align  Alignment
+	// MinWidth and MaxWidth bound the width of the column content, without
+	// the padding. A MaxWidth of 0 means no limit.
+	MinWidth, MaxWidth int
+	// Truncate the lines too long for the column with an ellipsis, instead
+	// of wrapping them.
+	Truncate bool
+}
+
+// Table render rows of cells in aligned columns, while handling properly
+// the terminal escape sequences, wide characters and multi-line cells.
+// Each line of a cell is rendered on its own, so that its style doesn't
+// leak in the neighbouring cells or borders.
+type Table struct {
+	Columns []TableColumn
+	Rows    [][]string
+
+	// Border to draw around and between the cells, or nil to only separate
+	// the columns with the padding.
+	Border *Border
+	// BorderStyle is the escape sequences applied to the border, like "\x1b[2m".
+	BorderStyle string
+	// Padding is the number of spaces on each side of the cells.
+	Padding int
+	// Width is the maximum total width of the table, or 0 for no limit. To fit,
+	// the widest columns are shrunk first, and their cells wrapped or truncated.
+	Width int
+}
+
+// NewTable create a Table with the given headers, with a single line border.
+func NewTable(headers ...string) *Table {
+	border := BorderSingle
+	t := &Table{
+		Border:  &border,
+		Padding: 1,
+	}
+	for _, header := range headers {
+		t.Columns = append(t.Columns, TableColumn{Header: header})
+	}
+	return t
+}
+
+// AddRow add a row of cells to the table.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Render return the table as text, with the number of lines.
+func (t *Table) Render() (string, int) {
+	columns := t.columns()
+	if len(columns) == 0 {
+		return "", 0
+	}
+
+	widths := t.columnWidths(columns)
+
+	var lines []string
+
+	if t.Border != nil {
+		lines = append(lines, t.separator(widths, t.Border.TopLeft, t.Border.TopJunction, t.Border.TopRight))
+	}
+
+	hasHeader := false
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+		hasHeader = hasHeader || column.Header != ""
+	}
+	if hasHeader {
+		lines = append(lines, t.row(columns, widths, headers)...)
+		if t.Border != nil {
+			lines = append(lines, t.separator(widths, t.Border.LeftJunction, t.Border.Cross, t.Border.RightJunction))
+		}
+	}
+
+	for _, row := range t.Rows {
+		lines = append(lines, t.row(columns, widths, row)...)
+	}
+
+	if t.Border != nil {
+		lines = append(lines, t.separator(widths, t.Border.BottomLeft, t.Border.BottomJunction, t.Border.BottomRight))
+	}
+
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// columns return the configuration of each column, including the columns
+// only defined by the cells of some rows.
+func (t *Table) columns() []TableColumn {
+	columns := append([]TableColumn(nil), t.Columns...)
+	for _, row := range t.Rows {
+		for len(columns) < len(row) {
+			columns = append(columns, TableColumn{})
+		}
+	}
+	return columns
+}
+
+// overhead return the total width of the table that is not cell content.
+func (t *Table) overhead(nbColumns int) int {
+	result := 2 * t.Padding * nbColumns
+	if t.Border != nil {
+		result += Len(t.Border.Vertical) * (nbColumns + 1)
+	}
+	return result
+}
+
+// columnWidths compute the width of the content of each column: its widest
+// cell, within the bounds of the column, then reduced if needed to fit the
+// total width of the table by shrinking the widest columns first.
+func (t *Table) columnWidths(columns []TableColumn) []int {
+	widths := make([]int, len(columns))
+	mins := make([]int, len(columns))
+
+	for i, column := range columns {
+		widths[i] = MaxLineLen(column.Header)
+		mins[i] = column.MinWidth
+		if mins[i] < 1 {
+			mins[i] = 1
+		}
+
+		cells := []string{column.Header}
+		for _, row := range t.Rows {
+			if i < len(row) {
+				cells = append(cells, row[i])
+			}
+		}
+		for _, cell := range cells {
+			if l := MaxLineLen(cell); l > widths[i] {
+				widths[i] = l
+			}
+			// the wrapping can't go below the widest character
+			if g := maxGraphemeWidth(cell); !column.Truncate && g > mins[i] {
+				mins[i] = g
+			}
+		}
+		if column.MaxWidth > 0 && widths[i] > column.MaxWidth {
+			widths[i] = column.MaxWidth
+		}
+		if widths[i] < mins[i] {
+			widths[i] = mins[i]
+		}
+	}
+
+	if t.Width <= 0 {
+		return widths
+	}
+
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+
+	for available := t.Width - t.overhead(len(columns)); total > available; total-- {
+		widest := -1
+		for i, w := range widths {
+			if w > mins[i] && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			// can't shrink further, the table will overflow
+			break
+		}
+		widths[widest]--
+	}
+
+	return widths
+}
+
+// separator return a horizontal line of the border.
+func (t *Table) separator(widths []int, left, junction, right string) string {
+	var sb strings.Builder
+	sb.WriteString(left)
+	for i, w := range widths {
+		if i > 0 {
+			sb.WriteString(junction)
+		}
+		sb.WriteString(strings.Repeat(t.Border.Horizontal, w+2*t.Padding))
+	}
+	sb.WriteString(right)
+	return styleBorder(t.BorderStyle, sb.String())
+}
+
+// row return the lines of a row of cells.
+func (t *Table) row(columns []TableColumn, widths []int, cells []string) []string {
+	height := 0
+	cellsLines := make([][]string, len(columns))
+	for i, column := range columns {
+		var cell string
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		cellsLines[i] = cellLines(cell, widths[i], column)
+		if len(cellsLines[i]) > height {
+			height = len(cellsLines[i])
+		}
+	}
+
+	var vertical string
+	if t.Border != nil {
+		vertical = styleBorder(t.BorderStyle, t.Border.Vertical)
+	}
+	padding := strings.Repeat(" ", t.Padding)
+
+	lines := make([]string, height)
+	for l := range lines {
+		var sb strings.Builder
+		sb.WriteString(vertical)
+		for i := range columns {
+			if i > 0 {
+				sb.WriteString(vertical)
+			}
+			line := ""
+			if l < len(cellsLines[i]) {
+				line = cellsLines[i][l]
+			}
+			sb.WriteString(padding)
+			sb.WriteString(line)
+			if lineLen := Len(line); lineLen < widths[i] {
+				sb.WriteString(strings.Repeat(" ", widths[i]-lineLen))
+			}
+			sb.WriteString(padding)
+		}
+		sb.WriteString(vertical)
+
+		// trailing spaces are not needed without border
+		lines[l] = sb.String()
+		if t.Border == nil {
+			lines[l] = strings.TrimRight(lines[l], " ")
+		}
+	}
+	return lines
+}
+
+// cellLines split the content of a cell into aligned lines fitting the given
+// width. Each line set again the escape state at its start and reset it at
+// its end, to render correctly on its own.
+func cellLines(cell string, width int, column TableColumn) []string {
+	var lines []string
+
+	if column.Truncate {
+		var state EscapeState
+		for _, line := range strings.Split(cell, "\n") {
+			prefix := state.FormatString()
+			state.Witness(line)
+			lines = append(lines, prefix+TruncateMax(line, width))
+		}
+	} else {
+		wrapped, _ := Wrap(cell, width, wrapIsolate())
+		lines = strings.Split(wrapped, "\n")
+	}
+
+	for i, line := range lines {
+		line = LineAlign(line, width, column.Align)
+
+		// each line start with the full escape state, that's enough to close it
+		var state EscapeState
+		state.Witness(line)
+		lines[i] = line + state.ResetString()
+	}
+
+	return lines
+}
@@ -0,0 +1,207 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable(t *testing.T) {
+	cases := []struct {
+		name   string
+		table  func() *Table
+		output string
+	}{
+		{
+			"empty",
+			func() *Table { return &Table{} },
+			"",
+		},
+		{
+			"simple",
+			func() *Table {
+				t := NewTable("Name", "Age")
+				t.AddRow("Alice", "31")
+				t.AddRow("Bob", "7")
+				return t
+			},
+			"┌───────┬─────┐\n" +
+				"│ Name  │ Age │\n" +
+				"├───────┼─────┤\n" +
+				"│ Alice │ 31  │\n" +
+				"│ Bob   │ 7   │\n" +
+				"└───────┴─────┘",
+		},
+		{
+			"no header, extra cells",
+			func() *Table {
+				t := NewTable()
+				t.Border = &BorderASCII
+				t.AddRow("a")
+				t.AddRow("b", "c")
+				return t
+			},
+			"+---+---+\n" +
+				"| a |   |\n" +
+				"| b | c |\n" +
+				"+---+---+",
+		},
+		{
+			"alignment and bounds",
+			func() *Table {
+				t := NewTable("L", "C", "R")
+				t.Columns[1].Align = AlignCenter
+				t.Columns[1].MinWidth = 5
+				t.Columns[2].Align = AlignRight
+				t.Columns[2].MaxWidth = 3
+				t.AddRow("x", "y", "zzzzz")
+				return t
+			},
+			"┌───┬───────┬─────┐\n" +
+				"│ L │   C   │   R │\n" +
+				"├───┼───────┼─────┤\n" +
+				"│ x │   y   │ zzz │\n" +
+				"│   │       │  zz │\n" +
+				"└───┴───────┴─────┘",
+		},
+		{
+			"multi-line cells",
+			func() *Table {
+				t := NewTable()
+				t.AddRow("foo\nbar\nbaz", "qux")
+				return t
+			},
+			"┌─────┬─────┐\n" +
+				"│ foo │ qux │\n" +
+				"│ bar │     │\n" +
+				"│ baz │     │\n" +
+				"└─────┴─────┘",
+		},
+		{
+			"wide characters",
+			func() *Table {
+				t := NewTable("狐狸", "x")
+				t.AddRow("a", "一只")
+				return t
+			},
+			"┌──────┬──────┐\n" +
+				"│ 狐狸 │ x    │\n" +
+				"├──────┼──────┤\n" +
+				"│ a    │ 一只 │\n" +
+				"└──────┴──────┘",
+		},
+		{
+			"width solver wraps the widest column",
+			func() *Table {
+				t := NewTable("Name", "Description")
+				t.Columns[1].Align = AlignRight
+				t.AddRow("foo", "一只敏捷的狐狸")
+				t.AddRow("a long cell content", "x")
+				t.Width = 24
+				return t
+			},
+			"┌──────────┬───────────┐\n" +
+				"│ Name     │ Descripti │\n" +
+				"│          │        on │\n" +
+				"├──────────┼───────────┤\n" +
+				"│ foo      │  一只敏捷 │\n" +
+				"│          │    的狐狸 │\n" +
+				"│ a long   │         x │\n" +
+				"│ cell     │           │\n" +
+				"│ content  │           │\n" +
+				"└──────────┴───────────┘",
+		},
+		{
+			"columns not narrower than a wide character",
+			func() *Table {
+				t := NewTable("a", "b")
+				t.Columns[1].MaxWidth = 1
+				t.AddRow("敏捷的狐狸", "一只")
+				t.Width = 9
+				return t
+			},
+			"┌────┬────┐\n" +
+				"│ a  │ b  │\n" +
+				"├────┼────┤\n" +
+				"│ 敏 │ 一 │\n" +
+				"│ 捷 │ 只 │\n" +
+				"│ 的 │    │\n" +
+				"│ 狐 │    │\n" +
+				"│ 狸 │    │\n" +
+				"└────┴────┘",
+		},
+		{
+			"flag after a zero-width space",
+			func() *Table {
+				t := NewTable()
+				t.AddRow("a\u200b\U0001f1eb\U0001f1f7")
+				t.Width = 3
+				return t
+			},
+			"┌────┐\n" +
+				"│ a\u200b  │\n" +
+				"│ \U0001f1eb\U0001f1f7 │\n" +
+				"└────┘",
+		},
+		{
+			"truncate without border",
+			func() *Table {
+				t := NewTable("Name", "Value")
+				t.Border = nil
+				t.Columns[0].Truncate = true
+				t.AddRow("a long cell content", "x")
+				t.Width = 20
+				return t
+			},
+			" Name         Value\n" +
+				" a long cel…  x",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			output, nbLines := c.table().Render()
+			assert.Equal(t, c.output, output)
+			if c.output == "" {
+				assert.Equal(t, 0, nbLines)
+			} else {
+				assert.Equal(t, strings.Count(c.output, "\n")+1, nbLines)
+			}
+		})
+	}
+}
+
+func TestNewTableBorder(t *testing.T) {
+	table := NewTable("a")
+	table.Border.Vertical = "!"
+
+	// the default border is not shared
+	assert.Equal(t, "│", BorderSingle.Vertical)
+	assert.Equal(t, "│", NewTable("b").Border.Vertical)
+}
+
+func TestTableStyle(t *testing.T) {
+	table := NewTable()
+	table.BorderStyle = "\x1b[2m"
+	table.AddRow("\x1b[31mred text\x1b[0m", "\x1b[1mbold")
+	table.AddRow("\x1b[4mfoo\nbar", "plain")
+	table.Columns = []TableColumn{{MaxWidth: 4}}
+
+	output, _ := table.Render()
+
+	expected := "\x1b[2m┌──────┬───────┐\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m \x1b[31mred\x1b[0m  \x1b[2m│\x1b[0m \x1b[1mbold\x1b[0m  \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m \x1b[31mtext\x1b[0m \x1b[2m│\x1b[0m       \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m \x1b[4mfoo\x1b[0m  \x1b[2m│\x1b[0m plain \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m│\x1b[0m \x1b[4mbar\x1b[0m  \x1b[2m│\x1b[0m       \x1b[2m│\x1b[0m\n" +
+		"\x1b[2m└──────┴───────┘\x1b[0m"
+	assert.Equal(t, expected, output)
+
+	// each line render on its own
+	for _, line := range strings.Split(output, "\n") {
+		var state EscapeState
+		state.Witness(line)
+		assert.True(t, state.IsZero(), "line %q", line)
+	}
+}
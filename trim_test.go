@@ -36,8 +36,8 @@ func TestTrimSpace(t *testing.T) {
 			"\x1b[31mbar\x1b[0m",
 		},
 		{
-			"  敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。   ",
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"  敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。   ",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 		},
 	}
 	for _, tc := range cases {
@@ -49,6 +49,6 @@ func TestTrimSpace(t *testing.T) {
 func BenchmarkTrimSpace(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		TrimSpace("  敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。   ")
+		TrimSpace("  敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。   ")
 	}
 }
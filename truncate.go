@@ -1,24 +1,174 @@
 package text
 
-import "github.com/mattn/go-runewidth"
+import (
+	"strings"
+)
+
+// TruncateSide is the part of a line removed by Truncate().
+type TruncateSide int
+
+const (
+	TruncateEnd TruncateSide = iota
+	TruncateStart
+	TruncateMiddle
+)
+
+type truncateOpts struct {
+	side       TruncateSide
+	ellipsis   string
+	separators string
+	tabWidth   int
+}
+
+// TruncateOption is a functional option for the Truncate() function
+type TruncateOption func(opts *truncateOpts)
+
+// TruncateAt configure the part of the line removed by Truncate(). The default
+// is TruncateEnd.
+func TruncateAt(side TruncateSide) TruncateOption {
+	return func(opts *truncateOpts) {
+		opts.side = side
+	}
+}
+
+// TruncateEllipsis configure the string inserted in place of the removed part
+// of the line. It can be styled with escape sequences. The default is "…".
+func TruncateEllipsis(ellipsis string) TruncateOption {
+	return func(opts *truncateOpts) {
+		opts.ellipsis = ellipsis
+	}
+}
+
+// TruncateBoundaries make Truncate() cut the line only next to one of the
+// given separator characters, like " " for words or "/" for paths. The
+// separators are kept next to the ellipsis, like in "/home/…/file.go".
+// If there is no such boundary within the allowed length, the line is cut
+// anywhere.
+func TruncateBoundaries(separators string) TruncateOption {
+	return func(opts *truncateOpts) {
+		opts.separators = separators
+	}
+}
 
 // TruncateMax truncate a line if its length is greater
 // than the given length. Otherwise, the line is returned
 // as is. If truncating occur, an ellipsis is inserted at
 // the end.
-// Handle properly terminal color escape code
-func TruncateMax(line string, length int) string {
+// Handle properly terminal color escape code: the escape
+// state at the cut is closed before the ellipsis, including
+// a hyperlink, so that nothing leaks into the ellipsis or
+// what follows. The escape sequences after the cut are
+// removed.
+// Tabs are expanded into spaces (see TabSize).
+// See Truncate for more options.
+func TruncateMax(line string, length int, opts ...TabOption) string {
 	if length <= 0 {
 		return "…"
 	}
+	return Truncate(line, length, TruncateTabWidth(tabWidth(opts)))
+}
+
+// TruncateTabWidth configure the distance between two tab stops. The default
+// is TabWidth.
+func TruncateTabWidth(tabWidth int) TruncateOption {
+	return func(opts *truncateOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+// Truncate truncate a line if its length is greater than the given length.
+// Otherwise, the line is returned as is. If truncating occur, an ellipsis is
+// inserted in place of the removed part, at the end of the line by default.
+// See TruncateAt, TruncateEllipsis and TruncateBoundaries for the options.
+// Handle properly terminal color escape code: the style of each kept part is
+// preserved, and closed before the ellipsis.
+// Tabs are expanded into spaces (see TruncateTabWidth).
+//
+// Required: The line shall not contain "\n"
+func Truncate(line string, length int, opts ...TruncateOption) string {
+	truncOpts := &truncateOpts{ellipsis: "…", tabWidth: TabWidth}
+	for _, opt := range opts {
+		opt(truncOpts)
+	}
 
-	l := Len(line)
-	if l <= length || l == 0 {
+	line = expandTabs(line, 0, truncOpts.tabWidth)
+
+	total := Len(line)
+	if total <= length {
 		return line
 	}
 
-	cleaned, escapes := ExtractTermEscapes(line)
-	truncated := runewidth.Truncate(cleaned, length-1, "")
+	available := length - Len(truncOpts.ellipsis)
+	if available <= 0 {
+		return truncOpts.ellipsis
+	}
+
+	// the start column of each grapheme, and if it's a separator
+	var starts []int
+	var separators []bool
+	col := 0
+	walkGraphemes(line, func(str string, escape bool, width int) bool {
+		if !escape {
+			starts = append(starts, col)
+			separators = append(separators, strings.Contains(truncOpts.separators, str))
+			col += width
+		}
+		return true
+	})
+
+	// headCut return the largest column where the head of the line can end,
+	// within the given width
+	headCut := func(width int, boundaries bool) int {
+		cut := 0
+		for i := range starts {
+			end := total
+			if i+1 < len(starts) {
+				end = starts[i+1]
+			}
+			if end <= width && (!boundaries || separators[i]) {
+				cut = end
+			}
+		}
+		return cut
+	}
+
+	// tailCut return the smallest column where the tail of the line can
+	// start, within the given width
+	tailCut := func(width int, boundaries bool) int {
+		for i := range starts {
+			if starts[i] >= total-width && (!boundaries || separators[i]) {
+				return starts[i]
+			}
+		}
+		return total
+	}
+
+	boundaries := truncOpts.separators != ""
+	head := func(width int) int {
+		cut := headCut(width, boundaries)
+		if cut == 0 {
+			cut = headCut(width, false)
+		}
+		return cut
+	}
+	tail := func(width int) int {
+		cut := tailCut(width, boundaries)
+		if cut == total {
+			cut = tailCut(width, false)
+		}
+		return cut
+	}
+
+	headEnd, tailStart := 0, total
+	switch truncOpts.side {
+	case TruncateEnd:
+		headEnd = head(available)
+	case TruncateStart:
+		tailStart = tail(available)
+	case TruncateMiddle:
+		headEnd = head((available + 1) / 2)
+		tailStart = tail(available - headEnd)
+	}
 
-	return ApplyTermEscapes(truncated, escapes) + "…"
+	return Slice(line, 0, headEnd) + truncOpts.ellipsis + Slice(line, tailStart, total)
 }
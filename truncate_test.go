@@ -43,13 +43,101 @@ func TestTruncateMax(t *testing.T) {
 			2,
 		},
 		{
-			"敏捷 A \x1b31mquick 的狐狸 fox 跳\x1b0m过 jumps over a lazy 了一只懒狗 dog。",
-			"敏捷 A \x1b31mquick \x1b0m…",
+			// the reset is after the cut
+			"\x1b[31mfoobar\x1b[0m baz",
+			"\x1b[31mfo\x1b[0m…",
+			3,
+		},
+		{
+			// the escapes after the cut are removed
+			"\x1b[1mfoo\x1b[0mbar\x1b[32m",
+			"\x1b[1mfoo\x1b[0m…",
+			4,
+		},
+		{
+			"a\tbcd",
+			"a   b…",
+			6,
+		},
+		{
+			"a\tb",
+			"a   b",
+			5,
+		},
+		{
+			"敏捷 A \x1b[31mquick 的狐狸 fox 跳\x1b[0m过 jumps over a lazy 了一只懒狗 dog。",
+			"敏捷 A \x1b[31mquick \x1b[0m…",
 			15,
 		},
+		{
+			"see \x1b]8;;https://example.com\x1b\\the docs\x1b]8;;\x1b\\ for more",
+			"see \x1b]8;;https://example.com\x1b\\th\x1b]8;;\x1b\\…",
+			7,
+		},
+		{
+			// unclosed link
+			"\x1b]8;;https://example.com\x1b\\the docs",
+			"\x1b]8;;https://example.com\x1b\\the\x1b]8;;\x1b\\…",
+			4,
+		},
+		{
+			// links after the cut are dropped
+			"foobar \x1b]8;;https://example.com\x1b\\docs\x1b]8;;\x1b\\",
+			"foo…",
+			4,
+		},
+		{
+			"cafe\u0301 \U0001f468\u200d\U0001f469\u200d\U0001f467 family",
+			"cafe\u0301 …",
+			7,
+		},
 	}
 	for _, tc := range cases {
 		out := TruncateMax(tc.line, tc.length)
 		assert.Equal(t, tc.output, out)
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	path := "/home/user/projects/go-term-text/truncate.go"
+	words := "The quick brown fox jumps over the lazy dog"
+
+	cases := []struct {
+		line   string
+		length int
+		opts   []TruncateOption
+		output string
+	}{
+		{"foo", 3, nil, "foo"},
+		{"foobar", 4, nil, "foo…"},
+		{"foobar", 0, nil, "…"},
+		{"foobar", 4, []TruncateOption{TruncateAt(TruncateStart)}, "…bar"},
+		{"foobar", 4, []TruncateOption{TruncateAt(TruncateMiddle)}, "fo…r"},
+		{"foobar", 5, []TruncateOption{TruncateAt(TruncateMiddle)}, "fo…ar"},
+		// custom ellipsis
+		{"foobar", 5, []TruncateOption{TruncateEllipsis("...")}, "fo..."},
+		{"foobar", 2, []TruncateOption{TruncateEllipsis("...")}, "..."},
+		{"foobar", 4, []TruncateOption{TruncateEllipsis("\x1b[2m…\x1b[0m"), TruncateAt(TruncateStart)}, "\x1b[2m…\x1b[0mbar"},
+		// boundaries
+		{path, 20, []TruncateOption{TruncateBoundaries("/")}, "/home/user/…"},
+		{path, 20, []TruncateOption{TruncateBoundaries("/"), TruncateAt(TruncateStart)}, "…/truncate.go"},
+		{path, 30, []TruncateOption{TruncateBoundaries("/"), TruncateAt(TruncateMiddle)}, "/home/user/…/truncate.go"},
+		{words, 20, []TruncateOption{TruncateBoundaries(" ")}, "The quick brown …"},
+		{words, 20, []TruncateOption{TruncateBoundaries(" "), TruncateAt(TruncateMiddle)}, "The quick … lazy dog"},
+		// no boundary within the length
+		{"verylongbranchname", 10, []TruncateOption{TruncateBoundaries("/")}, "verylongb…"},
+		{"feature/verylongbranchname", 10, []TruncateOption{TruncateBoundaries("/"), TruncateAt(TruncateStart)}, "…ranchname"},
+		// wide characters
+		{"一只狐狸", 5, []TruncateOption{TruncateAt(TruncateStart)}, "…狐狸"},
+		{"一只狐狸", 6, []TruncateOption{TruncateAt(TruncateMiddle)}, "一…狸"},
+		// escapes
+		{"\x1b[31mfoo\x1b[1mbar\x1b[0m", 4, []TruncateOption{TruncateAt(TruncateStart)}, "…\x1b[1;31mbar\x1b[0m"},
+		{"\x1b[31mfoobar\x1b[0m", 4, []TruncateOption{TruncateAt(TruncateMiddle)}, "\x1b[31mfo\x1b[0m…\x1b[31mr\x1b[0m"},
+		{"a\tbcd", 6, []TruncateOption{TruncateAt(TruncateStart)}, "…  bcd"},
+	}
+
+	for _, c := range cases {
+		output := Truncate(c.line, c.length, c.opts...)
+		assert.Equal(t, c.output, output, "line %q with length %d", c.line, c.length)
+	}
+}
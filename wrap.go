@@ -2,14 +2,24 @@ package text
 
 import (
 	"strings"
-
-	"github.com/mattn/go-runewidth"
+	"unicode/utf8"
 )
 
 type wrapOpts struct {
-	indent string
-	pad    string
-	align  Alignment
+	indent    string
+	pad       string
+	align     Alignment
+	lineBreak LineBreak
+	fitting   Fitting
+
+	hyphenation bool
+	hyphenator  *Hyphenator
+
+	tabWidth int
+
+	// isolate reset and set again the escape state around every line break,
+	// even without padding, so that each line render correctly on its own
+	isolate bool
 }
 
 // WrapOption is a functional option for the Wrap() function
@@ -43,13 +53,57 @@ func WrapAlign(align Alignment) WrapOption {
 	}
 }
 
+// WrapLineBreak configure how Wrap() find the places where a line can be broken
+func WrapLineBreak(lineBreak LineBreak) WrapOption {
+	return func(opts *wrapOpts) {
+		opts.lineBreak = lineBreak
+	}
+}
+
+// WrapFitting configure how Wrap() distribute the words between the lines
+func WrapFitting(fitting Fitting) WrapOption {
+	return func(opts *wrapOpts) {
+		opts.fitting = fitting
+	}
+}
+
+// WrapHyphenation enable the hyphenation for Wrap(): words can be broken at
+// their soft hyphens (U+00AD), and at the hyphenation points found by the
+// given Hyphenator. A visible hyphen is displayed at the chosen breaks.
+// With a nil Hyphenator, only the soft hyphens already in the text are used.
+func WrapHyphenation(hyphenator *Hyphenator) WrapOption {
+	return func(opts *wrapOpts) {
+		opts.hyphenation = true
+		opts.hyphenator = hyphenator
+	}
+}
+
+// WrapTabWidth configure the distance between two tab stops for Wrap().
+// The tabs are expanded into spaces up to the next tab stop, based on their
+// column in the line of the original text. The default is TabWidth.
+func WrapTabWidth(tabWidth int) WrapOption {
+	return func(opts *wrapOpts) {
+		opts.tabWidth = tabWidth
+	}
+}
+
+// wrapIsolate make each line of Wrap() render correctly on its own. See wrapOpts.isolate.
+func wrapIsolate() WrapOption {
+	return func(opts *wrapOpts) {
+		opts.isolate = true
+	}
+}
+
 // allWrapOpts compile the set of WrapOption into a final wrapOpts
 // from the default values.
 func allWrapOpts(opts []WrapOption) *wrapOpts {
 	wrapOpts := &wrapOpts{
-		indent: "",
-		pad:    "",
-		align:  NoAlign,
+		indent:    "",
+		pad:       "",
+		align:     NoAlign,
+		lineBreak: LineBreakSimple,
+		fitting:   FitGreedy,
+		tabWidth:  TabWidth,
 	}
 	for _, opt := range opts {
 		opt(wrapOpts)
@@ -71,97 +125,281 @@ func Wrap(text string, lineWidth int, opts ...WrapOption) (string, int) {
 		return "", 1
 	}
 
-	var result strings.Builder
-	var state EscapeState
-	nbLine := 0
+	if Len(wrapOpts.pad) >= lineWidth {
+		// padding is too wide, fallback rendering
+		return padFallback(lineWidth), 6
+	}
 
-	// output function to:
-	// - set the endlines (same as strings.Join())
-	// - reset and set again the escape state around the padding/indent
-	output := func(padding string, content string) {
-		zeroState := state.IsZero()
-		if !zeroState && len(padding) > 0 {
-			result.WriteString("\x1b[0m")
-		}
-		if nbLine > 0 {
-			result.WriteString("\n")
-		}
-		result.WriteString(padding)
-		if !zeroState && len(padding) > 0 {
-			result.WriteString(state.FormatString())
-		}
-		result.WriteString(content)
-		nbLine++
-		state.Witness(content)
+	w := newWrapper(lineWidth, wrapOpts, false)
+
+	text = expandTabs(text, 0, wrapOpts.tabWidth)
+
+	// NOTE: text is first segmented into lines so that wrapLine can handle individually
+	for _, line := range splitLines(text, wrapOpts.lineBreak) {
+		w.wrapLine(line, true)
 	}
 
-	if Len(wrapOpts.indent) >= lineWidth {
-		// indent is too wide, fallback rendering
-		output(strings.Repeat("⭬", lineWidth), "")
-		wrapOpts.indent = wrapOpts.pad
+	return w.out.String(), w.nbLine
+}
+
+// padFallback is the rendering of Wrap() when the padding is too wide.
+func padFallback(lineWidth int) string {
+	line := strings.Repeat("⭬", lineWidth)
+	return strings.Repeat(line+"\n", 5)
+}
+
+// wrapper hold the state of a wrapping in progress, so that a text can be
+// wrapped one line at a time.
+type wrapper struct {
+	opts      *wrapOpts
+	lineWidth int
+
+	out   strings.Builder
+	state EscapeState
+	// number of output lines
+	nbLine int
+	// true if the line break before the next output line is already written
+	broken bool
+
+	// number of complete input lines
+	nbInput int
+	// true if some lines of the current input line have been written already
+	continued bool
+
+	padStr string
+	padLen int
+
+	// the records of the output lines, only kept for WrapLines()
+	record bool
+	lines  []WrapLine
+	// src is the offset in the source text of each byte of the current input
+	// line, plus its end, only kept for WrapLines()
+	src []int
+}
+
+func newWrapper(lineWidth int, opts *wrapOpts, record bool) *wrapper {
+	w := &wrapper{
+		opts:      opts,
+		lineWidth: lineWidth,
+		record:    record,
 	}
-	if Len(wrapOpts.pad) >= lineWidth {
-		// padding is too wide, fallback rendering
-		line := strings.Repeat("⭬", lineWidth)
-		return strings.Repeat(line+"\n", 5), 6
+
+	if Len(opts.indent) >= lineWidth {
+		// indent is too wide, fallback rendering
+		w.output(strings.Repeat("⭬", lineWidth), "")
+		opts.indent = opts.pad
 	}
 
 	// Start with the indent
-	padStr := wrapOpts.indent
-	padLen := Len(wrapOpts.indent)
-
-	// tabs are formatted as 4 spaces
-	text = strings.Replace(text, "\t", "    ", -1)
-
-	// NOTE: text is first segmented into lines so that softwrapLine can handle individually
-	for i, line := range strings.Split(text, "\n") {
-		// on the second line, switch to use the padding instead
-		if i == 1 {
-			padStr = wrapOpts.pad
-			padLen = Len(wrapOpts.pad)
-		}
+	w.padStr = opts.indent
+	w.padLen = Len(opts.indent)
+
+	return w
+}
+
+// output write a line, with:
+// - the endlines (same as strings.Join())
+// - reset and set again the escape state around the padding/indent
+// - close and reopen the active hyperlink around the endlines
+func (w *wrapper) output(padding string, content string) {
+	if w.nbLine > 0 && !w.broken {
+		w.breakLine()
+	}
+	w.broken = false
+
+	if w.record {
+		w.lines = append(w.lines, WrapLine{
+			State:     w.state,
+			HardBreak: true,
+			begin:     w.out.Len(),
+			padWidth:  Len(padding),
+			content:   content,
+		})
+	}
+
+	w.out.WriteString(padding)
+	if len(w.padStr) > 0 || w.opts.isolate {
+		// the padding start from a reset state, but can leave some style
+		var padState EscapeState
+		padState.Witness(padding)
+		w.out.WriteString(padState.TransitionString(&w.state))
+	} else {
+		w.out.WriteString(w.state.Link.OpenString())
+	}
+	w.out.WriteString(content)
+	w.nbLine++
+	w.state.Witness(content)
+}
+
+// breakLine write the line break before the next output line.
+func (w *wrapper) breakLine() {
+	if len(w.padStr) > 0 || w.opts.isolate {
+		w.out.WriteString(w.state.ResetString())
+	} else {
+		w.out.WriteString(w.state.Link.CloseString())
+	}
+	w.out.WriteString("\n")
+	w.broken = true
+}
+
+// setPadding switch from the indent to the padding for the next lines.
+func (w *wrapper) setPadding() {
+	w.padStr = w.opts.pad
+	w.padLen = Len(w.opts.pad)
+}
 
-		if line == "" || strings.TrimSpace(line) == "" {
+// wrapLine wrap an input line and write the resulting lines.
+//
+// If final is false, more text can still be added to the line: only the lines
+// that can't change anymore are written, and the remaining text is returned.
+// This is only supported with the greedy fitting and the simple line breaking
+// without hyphenation, otherwise nothing is written.
+//
+// Required: The line shall not contain '\n'
+func (w *wrapper) wrapLine(line string, final bool) string {
+	if !final && !w.canWrapPartial() {
+		return line
+	}
+
+	if !w.continued && strings.TrimSpace(line) == "" {
+		if final {
 			// nothing in the line, we just add the non-empty part of the padding
-			output(strings.TrimRight(padStr, " "), "")
-			continue
+			w.output(strings.TrimRight(w.padStr, " "), "")
+			w.recordSource(line, 0, true, true)
+			w.endInput()
 		}
+		return line
+	}
+
+	if final && w.opts.hyphenator != nil {
+		hyphenated := w.opts.hyphenator.insertSoftHyphens(line)
+		if w.record {
+			w.src = insertedOffsets(line, hyphenated, w.src)
+		}
+		line = hyphenated
+	}
+
+	// text is the part of the line that can be wrapped
+	text := line
+	if !final {
+		text = line[:stableLen(line)]
+	}
 
-		wrapped := softwrapLine(line, lineWidth-padLen)
-		split := strings.Split(wrapped, "\n")
+	if w.nbInput == 0 && !w.continued {
+		split := strings.Split(softwrapLine(text, w.lineWidth-w.padLen, w.opts), "\n")
 
-		if i == 0 && len(split) > 1 {
+		if len(split) > 1 {
 			// the very first line got wrapped.
 			// that means we need to use the indent, use the first wrapped line, discard the rest
 			// switch to the normal padding, do the softwrap again with the remainder,
 			// and fallback to the normal wrapping flow
+			content := w.opts.render(strings.TrimRight(split[0], " "), true)
+			w.output(w.padStr, LineAlign(content, w.lineWidth-w.padLen, w.opts.align))
+			w.recordSource(split[0], 0, false, w.opts.align != NoAlign)
 
-			content := LineAlign(strings.TrimRight(split[0], " "), lineWidth-padLen, wrapOpts.align)
-			output(padStr, content)
+			rest := strings.TrimLeft(strings.TrimPrefix(line, split[0]), " ")
+			if w.record {
+				w.src = w.src[len(line)-len(rest):]
+			}
+			line = rest
+			text = strings.TrimLeft(strings.TrimPrefix(text, split[0]), " ")
+			w.setPadding()
+			w.continued = true
+		} else if !final {
+			return line
+		}
+	}
+
+	split := strings.Split(softwrapLine(text, w.lineWidth-w.padLen, w.opts), "\n")
+
+	// the last line of a partial text can still change
+	n := len(split)
+	if !final {
+		n--
+	}
 
-			line = strings.TrimPrefix(line, split[0])
-			line = strings.TrimLeft(line, " ")
+	consumed := 0
+	for j, seg := range split[:n] {
+		start := consumed
+		consumed += len(seg)
 
-			padStr = wrapOpts.pad
-			padLen = Len(wrapOpts.pad)
+		align := w.opts.align
+		if align == AlignJustify && final && j == len(split)-1 {
+			// the last line of a paragraph is not justified
+			align = AlignLeft
+		}
+		broken := !final || j < len(split)-1
 
-			wrapped = softwrapLine(line, lineWidth-padLen)
-			split = strings.Split(wrapped, "\n")
+		keepLeft := j == 0 && !w.continued
+		var content string
+		if keepLeft {
+			// keep the left padding of the wrapped line
+			content = w.opts.render(strings.TrimRight(seg, " "), broken)
+		} else {
+			content = w.opts.render(strings.TrimSpace(seg), broken)
 		}
+		w.output(w.padStr, LineAlign(content, w.lineWidth-w.padLen, align))
+		w.recordSource(seg, start, !broken, !keepLeft || align != NoAlign)
+	}
+
+	if final {
+		w.endInput()
+		return ""
+	}
+
+	if n > 0 {
+		w.continued = true
+	}
+	return line[consumed:]
+}
+
+// endInput terminate the current input line.
+func (w *wrapper) endInput() {
+	w.nbInput++
+	w.continued = false
+	// on the second line, switch to use the padding instead
+	w.setPadding()
+}
+
+// canWrapPartial return true if the lines of a partial input line can be
+// written before the end of the input line.
+func (w *wrapper) canWrapPartial() bool {
+	return w.opts.fitting == FitGreedy &&
+		w.opts.lineBreak == LineBreakSimple &&
+		!w.opts.hyphenation
+}
 
-		for j, seg := range split {
-			if j == 0 {
-				// keep the left padding of the wrapped line
-				content := LineAlign(strings.TrimRight(seg, " "), lineWidth-padLen, wrapOpts.align)
-				output(padStr, content)
-			} else {
-				content := LineAlign(strings.TrimSpace(seg), lineWidth-padLen, wrapOpts.align)
-				output(padStr, content)
+// stableLen return the length of the beginning of a partial line that is not
+// affected by the text that can still be appended to the line: up to the last
+// group of spaces, outside of the escape sequences.
+func stableLen(line string) int {
+	result := 0
+	prevSpace := false
+	for i := 0; i < len(line); {
+		if n := escapeLen(line[i:]); n > 0 {
+			i += n
+			continue
+		}
+		if line[i] == ' ' {
+			if !prevSpace {
+				result = i
 			}
+			prevSpace = true
+		} else {
+			prevSpace = false
 		}
+		i++
 	}
+	return result
+}
 
-	return result.String(), nbLine
+// render prepare a wrapped line for display. broken tell if the line was
+// broken at its end by the wrapping.
+func (opts *wrapOpts) render(line string, broken bool) string {
+	if !opts.hyphenation {
+		return line
+	}
+	return renderSoftHyphens(line, broken)
 }
 
 // WrapLeftPadded wrap a text for a given line size with a left padding.
@@ -200,10 +438,21 @@ func WrapWithPadIndentAlign(text string, lineWidth int, indent string, pad strin
 	return Wrap(text, lineWidth, WrapIndent(indent), WrapPad(pad), WrapAlign(align))
 }
 
+// splitLines split a text into lines at the explicit line breaks. With the
+// Unicode line breaking, the other mandatory breaks are honored as well, and
+// "\r\n" is a single break.
+func splitLines(text string, lineBreak LineBreak) []string {
+	if lineBreak != LineBreakUnicode {
+		return strings.Split(text, "\n")
+	}
+	return splitMandatoryBreaks(text)
+}
+
 // Break a line into several lines so that each line consumes at most
 // 'lineWidth' cells.  Lines break at groups of white spaces and multicell
-// chars. Nothing is removed from the original text so that it behaves like a
-// softwrap.
+// chars, or at the break opportunities of the Unicode line breaking algorithm
+// if requested. Nothing is removed from the original text so that it behaves
+// like a softwrap.
 //
 // Required: The line shall not contain '\n'
 //
@@ -211,10 +460,74 @@ func WrapWithPadIndentAlign(text string, lineWidth int, indent string, pad strin
 // breaks ("\n") are inserted between these groups so that the total length
 // between breaks does not exceed the required width. Words that are longer than
 // the textWidth are broken into pieces no longer than textWidth.
-func softwrapLine(line string, lineWidth int) string {
+// Depending on the options, the line breaks are placed greedily or to
+// minimize the raggedness of the whole line (see fitOptimal).
+func softwrapLine(line string, lineWidth int, opts *wrapOpts) string {
 	escaped, escapes := ExtractTermEscapes(line)
 
-	chunks := segmentLine(escaped)
+	var chunks []string
+	switch opts.lineBreak {
+	case LineBreakUnicode:
+		chunks = segmentLineUnicode(escaped)
+	default:
+		chunks = segmentLine(escaped)
+	}
+	if opts.hyphenation {
+		chunks = splitSoftHyphens(chunks)
+	}
+
+	var out strings.Builder
+
+	// helper to write in the output while interleaving the escape
+	// sequence at the correct places.
+	// note: the final algorithm will add additional line break in the original
+	// text. Those line break are *not* fed to this helper so the positions don't
+	// need to be offset, which make the whole thing much easier.
+	currPos := 0
+	currItem := 0
+	outputString := func(s string) {
+		for _, r := range s {
+			for currItem < len(escapes) && currPos == escapes[currItem].Pos {
+				out.WriteString(escapes[currItem].Item)
+				currItem++
+			}
+			out.WriteRune(r)
+			currPos++
+		}
+	}
+
+	var lines [][]string
+	if opts.fitting == FitOptimal {
+		lines = fitOptimal(chunks, lineWidth, opts.hyphenation)
+	}
+
+	if lines != nil {
+		for i, l := range lines {
+			if i > 0 {
+				out.WriteRune('\n')
+			}
+			for _, chunk := range l {
+				outputString(chunk)
+			}
+		}
+	} else {
+		// greedy fitting, or fallback when no optimal layout fits the width
+		softwrapGreedy(chunks, lineWidth, opts.hyphenation, &out, outputString)
+	}
+
+	// Don't forget the trailing escapes, if any.
+	for currItem < len(escapes) && currPos >= escapes[currItem].Pos {
+		out.WriteString(escapes[currItem].Item)
+		currItem++
+	}
+
+	return out.String()
+}
+
+// softwrapGreedy fill each line with as many chunks as possible before going to
+// the next one. With hyphenation, room is kept for the hyphen after a chunk
+// ending with a soft hyphen.
+func softwrapGreedy(chunks []string, lineWidth int, hyphenation bool, out *strings.Builder, outputString func(s string)) {
 	// Reverse the chunk array so we can use it as a stack.
 	for i, j := 0, len(chunks)-1; i < j; i, j = i+1, j-1 {
 		chunks[i], chunks[j] = chunks[j], chunks[i]
@@ -240,41 +553,27 @@ func softwrapLine(line string, lineWidth int) string {
 		return len(chunks) == 0
 	}
 
-	var out strings.Builder
-
-	// helper to write in the output while interleaving the escape
-	// sequence at the correct places.
-	// note: the final algorithm will add additional line break in the original
-	// text. Those line break are *not* fed to this helper so the positions don't
-	// need to be offset, which make the whole thing much easier.
-	currPos := 0
-	currItem := 0
-	outputString := func(s string) {
-		for _, r := range s {
-			for currItem < len(escapes) && currPos == escapes[currItem].Pos {
-				out.WriteString(escapes[currItem].Item)
-				currItem++
-			}
-			out.WriteRune(r)
-			currPos++
-		}
-	}
-
 	width := 0
 
 	for !empty() {
 		wl := Len(peek())
 
-		if width+wl <= lineWidth {
+		// a visible hyphen is needed if the line is broken after the chunk
+		hyphen := 0
+		if hyphenation && len(chunks) > 1 && endsWithSoftHyphen(peek()) {
+			hyphen = 1
+		}
+
+		if width+wl+hyphen <= lineWidth {
 			// the chunk fit in the available space
 			outputString(pop())
 			width += wl
-			if width == lineWidth && !empty() {
+			if width+hyphen == lineWidth && !empty() {
 				// only add line break when there is more chunk to come
 				out.WriteRune('\n')
 				width = 0
 			}
-		} else if wl > lineWidth {
+		} else if wl+hyphen > lineWidth {
 			// words too long for a full line are split to fill the remaining space.
 			// But if the long words is the first non-space word in the middle of the
 			// line, preceding spaces shall not be counted in word splitting.
@@ -283,10 +582,22 @@ func softwrapLine(line string, lineWidth int) string {
 				splitWidth += width
 			}
 			left, right := splitWord(pop(), splitWidth)
+			if Len(left) > splitWidth && splitWidth < lineWidth {
+				// a wide character doesn't fit in the remaining space, it
+				// goes to the next line
+				push(left + right)
+				out.WriteRune('\n')
+				width = 0
+				continue
+			}
 			// remainder is pushed back to the stack for next round
-			push(right)
+			if right != "" {
+				push(right)
+			}
 			outputString(left)
-			out.WriteRune('\n')
+			if !empty() {
+				out.WriteRune('\n')
+			}
 			width = 0
 		} else {
 			// normal line overflow, we add a line break and try again
@@ -294,18 +605,10 @@ func softwrapLine(line string, lineWidth int) string {
 			width = 0
 		}
 	}
-
-	// Don't forget the trailing escapes, if any.
-	for currItem < len(escapes) && currPos >= escapes[currItem].Pos {
-		out.WriteString(escapes[currItem].Item)
-		currItem++
-	}
-
-	return out.String()
 }
 
-// Segment a line into chunks, where each chunk consists of chars with the same
-// type and is not breakable.
+// Segment a line into chunks, where each chunk consists of grapheme clusters
+// with the same type and is not breakable.
 func segmentLine(s string) []string {
 	var chunks []string
 
@@ -317,15 +620,15 @@ func segmentLine(s string) []string {
 		wordType = none
 	}
 
-	for _, r := range s {
+	walkGraphemes(s, func(cluster string, _ bool, width int) bool {
 		// A WIDE_CHAR itself constitutes a chunk.
-		thisType := runeType(r)
+		thisType := graphemeType(cluster, width)
 		if thisType == wideChar {
 			if wordType != none {
 				flushWord()
 			}
-			chunks = append(chunks, string(r))
-			continue
+			chunks = append(chunks, cluster)
+			return true
 		}
 		// Other type of chunks starts with a char of that type, and ends with a
 		// char with different type or end of string.
@@ -333,12 +636,13 @@ func segmentLine(s string) []string {
 			if wordType != none {
 				flushWord()
 			}
-			word = string(r)
+			word = cluster
 			wordType = thisType
 		} else {
-			word += string(r)
+			word += cluster
 		}
-	}
+		return true
+	})
 	if word != "" {
 		flushWord()
 	}
@@ -361,12 +665,12 @@ const (
 	visibleAscii
 )
 
-// Determine the category of a rune.
-func runeType(r rune) RuneType {
-	rw := runewidth.RuneWidth(r)
-	if rw > 1 {
+// Determine the category of a grapheme cluster, given its width.
+func graphemeType(cluster string, width int) RuneType {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	if width > 1 {
 		return wideChar
-	} else if rw == 0 {
+	} else if width == 0 {
 		return invisible
 	} else if r > 127 {
 		return shortUnicode
@@ -377,43 +681,27 @@ func runeType(r rune) RuneType {
 	}
 }
 
-// splitWord split a word at the given length, while ignoring the terminal escape sequences
+// splitWord split a word at the given length, while ignoring the terminal escape sequences.
+// The word is only split on grapheme cluster boundaries. Unless the length is
+// zero, at least one grapheme cluster is taken, even if it's wider than the
+// length.
 func splitWord(word string, length int) (string, string) {
-	runes := []rune(word)
-	var result []rune
-	added := 0
-	escape := false
-
 	if length == 0 {
 		return "", word
 	}
 
-	for _, r := range runes {
-		if r == '\x1b' {
-			escape = true
-		}
+	added := 0
+	end := 0
 
-		width := runewidth.RuneWidth(r)
-		if width+added > length {
+	walkGraphemes(word, func(str string, _ bool, width int) bool {
+		if width+added > length && added > 0 {
 			// wide character made the length overflow
-			break
+			return false
 		}
+		end += len(str)
+		added += width
+		return added < length
+	})
 
-		result = append(result, r)
-
-		if !escape {
-			added += width
-			if added >= length {
-				break
-			}
-		}
-
-		if r == 'm' {
-			escape = false
-		}
-	}
-
-	leftover := runes[len(result):]
-
-	return string(result), string(leftover)
+	return word[:end], word[end:]
 }
@@ -0,0 +1,261 @@
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// WrapLine is a line of text wrapped by WrapLines(), with the information
+// needed to relate it to the source text.
+type WrapLine struct {
+	// Content is the line as written by Wrap(), including the padding and
+	// the escape sequences.
+	Content string
+	// Width is the visual width of Content.
+	Width int
+	// Start and End are the byte range of the source text displayed on
+	// this line. The line breaks are not included.
+	Start, End int
+	// HardBreak is true if the line ends at a line break of the source text,
+	// or at its end, and false if the line has been broken by the wrapping.
+	HardBreak bool
+	// State is the escape state active at the start of the line content,
+	// after the padding.
+	State EscapeState
+
+	// offset of the line in the output of Wrap()
+	begin int
+	// width of the padding, and the line content without the padding and the
+	// escape sequences added around it
+	padWidth int
+	content  string
+	// columns is the source offset of each column of the line, or -1 for the
+	// columns not coming from the source text, like padding and alignment.
+	columns []int
+	// sourced is true if the line display a part of the source text
+	sourced bool
+}
+
+// WrapLines wrap a text for a given line size, like Wrap(), but return the
+// lines with the information needed to relate them to the source text, as
+// needed for cursor placement or selection.
+// Joining the Content of the lines with "\n" gives the output of Wrap().
+func WrapLines(text string, lineWidth int, opts ...WrapOption) []WrapLine {
+	wrapOpts := allWrapOpts(opts)
+
+	if lineWidth <= 0 {
+		return []WrapLine{{HardBreak: true}}
+	}
+
+	if Len(wrapOpts.pad) >= lineWidth {
+		// padding is too wide, fallback rendering
+		var lines []WrapLine
+		for _, l := range strings.Split(padFallback(lineWidth), "\n") {
+			lines = append(lines, WrapLine{Content: l, Width: Len(l), HardBreak: true})
+		}
+		return lines
+	}
+
+	w := newWrapper(lineWidth, wrapOpts, true)
+
+	pos := 0
+	for i, line := range splitLines(text, wrapOpts.lineBreak) {
+		if i > 0 {
+			// skip the line break, a single character or "\r\n"
+			_, n := utf8.DecodeRuneInString(text[pos:])
+			if wrapOpts.lineBreak == LineBreakUnicode && strings.HasPrefix(text[pos:], "\r\n") {
+				n = 2
+			}
+			pos += n
+		}
+
+		// tabs are expanded into spaces, all mapped to the tab
+		w.src = w.src[:0]
+		var expanded strings.Builder
+		col := 0
+		walkGraphemes(line, func(str string, _ bool, width int) bool {
+			if str == "\t" {
+				width = tabStopWidth(col, wrapOpts.tabWidth)
+				expanded.WriteString(strings.Repeat(" ", width))
+				for k := 0; k < width; k++ {
+					w.src = append(w.src, pos)
+				}
+			} else {
+				expanded.WriteString(str)
+				for k := 0; k < len(str); k++ {
+					w.src = append(w.src, pos+k)
+				}
+			}
+			col += width
+			pos += len(str)
+			return true
+		})
+		w.src = append(w.src, pos)
+
+		w.wrapLine(expanded.String(), true)
+	}
+
+	out := w.out.String()
+	for i := range w.lines {
+		l := &w.lines[i]
+		end := len(out)
+		if i+1 < len(w.lines) {
+			// the lines are separated by a single "\n"
+			end = w.lines[i+1].begin - 1
+		}
+		l.Content = out[l.begin:end]
+		l.Width = Len(l.Content)
+	}
+
+	return w.lines
+}
+
+// recordSource complete the record of the last output line with the part of
+// the current input line it displays, starting at the given byte offset.
+// trimLeft tell if the leading spaces of the segment have been removed.
+func (w *wrapper) recordSource(seg string, at int, hard bool, trimLeft bool) {
+	if !w.record {
+		return
+	}
+
+	l := &w.lines[len(w.lines)-1]
+	l.Start = w.src[at]
+	l.End = w.src[at+len(seg)]
+	l.HardBreak = hard
+	l.sourced = true
+
+	// match the clusters of the content with the ones of the segment, the
+	// differences being the spaces added or removed by the trimming and the
+	// alignment, and the rendering of the soft hyphens
+	type cluster struct {
+		str    string
+		offset int
+	}
+	var source []cluster
+	pos := at
+	walkGraphemes(seg, func(str string, escape bool, _ int) bool {
+		if !escape {
+			source = append(source, cluster{str, w.src[pos]})
+		}
+		pos += len(str)
+		return true
+	})
+
+	for trimLeft && len(source) > 0 && source[0].str == " " {
+		source = source[1:]
+	}
+
+	l.columns = make([]int, 0, l.padWidth+Len(l.content))
+	for i := 0; i < l.padWidth; i++ {
+		l.columns = append(l.columns, -1)
+	}
+
+	walkGraphemes(l.content, func(str string, escape bool, width int) bool {
+		if escape {
+			return true
+		}
+		offset := -1
+		for len(source) > 0 {
+			s := source[0]
+			if s.str == str || (s.str == string(softHyphen) && str == "-") {
+				offset = s.offset
+				source = source[1:]
+				break
+			}
+			if str == " " {
+				// added by the alignment
+				break
+			}
+			// removed by the trimming or the rendering
+			source = source[1:]
+		}
+		for k := 0; k < width; k++ {
+			l.columns = append(l.columns, offset)
+		}
+		return true
+	})
+
+	l.content = ""
+}
+
+// insertedOffsets return the source offsets of a line after some characters
+// have been inserted in it. The inserted characters take the offset of the
+// following one.
+func insertedOffsets(before, after string, src []int) []int {
+	result := make([]int, 0, len(after)+1)
+	i := 0
+	for _, r := range after {
+		n := utf8.RuneLen(r)
+		inserted := i >= len(before) || !strings.HasPrefix(before[i:], string(r))
+		for k := 0; k < n; k++ {
+			if inserted {
+				result = append(result, src[i])
+			} else {
+				result = append(result, src[i+k])
+			}
+		}
+		if !inserted {
+			i += n
+		}
+	}
+	return append(result, src[len(before)])
+}
+
+// Offset return the offset in the source text of the given column of the line.
+// Columns without a source character, like the padding, give the offset of the
+// next character of the line, or the end of the line.
+func (l WrapLine) Offset(col int) int {
+	if col < 0 {
+		col = 0
+	}
+	for ; col < len(l.columns); col++ {
+		if l.columns[col] >= 0 {
+			return l.columns[col]
+		}
+	}
+	return l.End
+}
+
+// Column return the column of the line displaying the given offset of the
+// source text. An offset without a matching column, like a removed space, gives
+// the column of the next displayed character, or the width of the line.
+func (l WrapLine) Column(offset int) int {
+	for col, o := range l.columns {
+		if o >= offset {
+			return col
+		}
+	}
+	return l.Width
+}
+
+// WrapOffset return the offset in the source text displayed at the given row
+// and column of the lines returned by WrapLines().
+func WrapOffset(lines []WrapLine, row int, col int) int {
+	if len(lines) == 0 || row < 0 {
+		return 0
+	}
+	if row >= len(lines) {
+		return lines[len(lines)-1].End
+	}
+	return lines[row].Offset(col)
+}
+
+// WrapPosition return the row and column displaying the given offset of the
+// source text in the lines returned by WrapLines().
+func WrapPosition(lines []WrapLine, offset int) (row int, col int) {
+	last := -1
+	for i, l := range lines {
+		if !l.sourced {
+			// fallback line not related to the source
+			continue
+		}
+		if offset < l.End || (offset == l.End && l.HardBreak) {
+			return i, l.Column(offset)
+		}
+		last = i
+	}
+	if last < 0 {
+		return 0, 0
+	}
+	return last, lines[last].Width
+}
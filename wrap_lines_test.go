@@ -0,0 +1,173 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapLinesContent(t *testing.T) {
+	inputs := []string{
+		"",
+		"foo\n",
+		"\n\nfoo\n\n",
+		"foo\r\nbar\r\n",
+		"The \x1b[1mLorem ipsum\x1b[0m text is typically composed of pseudo-Latin words.\n" +
+			"It is commonly used as \x1b[3mplaceholder\x1b[0m text\tto examine or demonstrate",
+		"一只 A Quick \x1b[31m敏捷的狐 Fox 狸跳过了\x1b[0mDog一只懒狗。",
+		"a veryveryveryveryverylongword and \x1b]8;;http://example.com\x1b\\a link\x1b]8;;\x1b\\",
+	}
+
+	options := [][]WrapOption{
+		nil,
+		{WrapIndent("\x1b[34m<-indent-> \x1b[0m"), WrapPad("\x1b[33m<-pad-> \x1b[0m")},
+		{WrapPad("  "), WrapAlign(AlignRight)},
+		{WrapAlign(AlignJustify), WrapFitting(FitOptimal)},
+		{WrapLineBreak(LineBreakUnicode), WrapHyphenation(EnglishHyphenator())},
+	}
+
+	for _, input := range inputs {
+		for i, opts := range options {
+			for _, width := range []int{0, 4, 10, 20} {
+				expected, nbLines := Wrap(input, width, opts...)
+
+				lines := WrapLines(input, width, opts...)
+				assert.Len(t, lines, nbLines)
+
+				var contents []string
+				for _, l := range lines {
+					contents = append(contents, l.Content)
+					assert.Equal(t, Len(l.Content), l.Width)
+				}
+				assert.Equal(t, expected, strings.Join(contents, "\n"), "input %q options %d width %d", input, i, width)
+			}
+		}
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	lines := WrapLines("The \x1b[31mquick brown\x1b[0m fox\n\tfoo", 10, WrapPad("> "))
+
+	expected := []struct {
+		content    string
+		start, end int
+		hard       bool
+		state      EscapeState
+	}{
+		{"> The", 0, 4, false, EscapeState{}},
+		{"> \x1b[31mquick\x1b[0m", 4, 15, false, EscapeState{}},
+		{"> \x1b[31mbrown\x1b[0m", 15, 25, false, EscapeState{FgColor: ColorIndex(31)}},
+		{"> fox", 25, 28, true, EscapeState{}},
+		{">     foo", 29, 33, true, EscapeState{}},
+	}
+
+	assert.Len(t, lines, len(expected))
+	for i, e := range expected {
+		assert.Equal(t, e.content, lines[i].Content, "line %d", i)
+		assert.Equal(t, e.start, lines[i].Start, "line %d", i)
+		assert.Equal(t, e.end, lines[i].End, "line %d", i)
+		assert.Equal(t, e.hard, lines[i].HardBreak, "line %d", i)
+		assert.Equal(t, e.state, lines[i].State, "line %d", i)
+	}
+
+	// the columns of the expanded tab are all mapped to it
+	for col := 2; col < 6; col++ {
+		assert.Equal(t, 29, lines[4].Offset(col))
+	}
+	assert.Equal(t, 30, lines[4].Offset(6))
+	assert.Equal(t, 6, lines[4].Column(30))
+}
+
+func TestWrapLinesCRLF(t *testing.T) {
+	lines := WrapLines("foo\r\nbar\u2028baz", 10, WrapLineBreak(LineBreakUnicode))
+
+	assert.Len(t, lines, 3)
+	for i, e := range []struct {
+		content    string
+		start, end int
+	}{{"foo", 0, 3}, {"bar", 5, 8}, {"baz", 11, 14}} {
+		assert.Equal(t, e.content, lines[i].Content, "line %d", i)
+		assert.Equal(t, e.start, lines[i].Start, "line %d", i)
+		assert.Equal(t, e.end, lines[i].End, "line %d", i)
+	}
+}
+
+func TestWrapLinesCRLFAfterControl(t *testing.T) {
+	for _, text := range []string{"\u200b\r\nfoo", "a\t\r\nb", "a\u00ad\r\n\r\nb\a\r\nc"} {
+		opts := []WrapOption{WrapLineBreak(LineBreakUnicode)}
+		wrapped, nbLines := Wrap(text, 10, opts...)
+		lines := WrapLines(text, 10, opts...)
+
+		assert.Len(t, lines, nbLines, "%q", text)
+
+		var contents []string
+		for _, l := range lines {
+			contents = append(contents, l.Content)
+			assert.True(t, l.Start <= l.End && l.End <= len(text), "%q: %d-%d", text, l.Start, l.End)
+		}
+		assert.Equal(t, wrapped, strings.Join(contents, "\n"), "%q", text)
+	}
+}
+
+func TestWrapOffsetPosition(t *testing.T) {
+	text := "The \x1b[31mquick brown\x1b[0m 狐狸\n\tfoo"
+	lines := WrapLines(text, 10, WrapPad("> "), WrapAlign(AlignRight))
+
+	// ">      The"
+	// ">    quick"
+	// "> brown 狐"
+	// ">       狸"
+	// ">      foo"
+
+	cases := []struct {
+		row, col int
+		offset   int
+	}{
+		// padding and alignment give the first character of the line
+		{0, 0, 0},
+		{0, 7, 0},
+		{0, 9, 2},
+		{1, 5, 9},
+		{1, 9, 13},
+		{2, 5, 18},
+		{2, 7, 24},
+		// wide characters spread on two columns
+		{2, 8, 25},
+		{2, 9, 25},
+		{3, 0, 28},
+		{3, 9, 28},
+		{4, 7, 33},
+		// after the end of the line
+		{4, 12, 36},
+		{5, 0, 36},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.offset, WrapOffset(lines, c.row, c.col), "row %d col %d", c.row, c.col)
+	}
+
+	positions := []struct {
+		offset   int
+		row, col int
+	}{
+		{0, 0, 7},
+		{2, 0, 9},
+		// the space removed at the end of the line
+		{3, 0, 10},
+		{9, 1, 5},
+		{25, 2, 8},
+		{28, 3, 8},
+		// the line break
+		{31, 3, 10},
+		// the tab removed by the alignment
+		{32, 4, 7},
+		{len(text), 4, 10},
+	}
+
+	for _, p := range positions {
+		row, col := WrapPosition(lines, p.offset)
+		assert.Equal(t, p.row, row, "offset %d", p.offset)
+		assert.Equal(t, p.col, col, "offset %d", p.offset)
+	}
+}
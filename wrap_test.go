@@ -41,10 +41,12 @@ func TestWrap(t *testing.T) {
 			"fo\nsop",
 			4,
 		},
-		// A tab counts as 4 characters.
+		// A tab advances to the next tab stop, every 4 characters.
+		// Here the tab and the following space make a run of whitespace as
+		// wide as the line, giving an empty line like below.
 		{
 			"foo\nb\t r\n baz",
-			"foo\nb\nr\n baz",
+			"foo\nb\n\nr\n baz",
 			4,
 		},
 		// Trailing whitespace is removed after used for wrapping.
@@ -72,6 +74,18 @@ func TestWrap(t *testing.T) {
 			"foo\n\x1b[31mbar\x1b[0m\nbaz",
 			4,
 		},
+		// Handle escapes that don't end with 'm'
+		{
+			"foo \x1b[2Kbar\x1b]0;title\x07 baz",
+			"foo\n\x1b[2Kbar\x1b]0;title\x07\nbaz",
+			4,
+		},
+		// Close and reopen hyperlinks around line breaks
+		{
+			"foo \x1b]8;;https://example.com\x1b\\bar baz\x1b]8;;\x1b\\",
+			"foo\n\x1b]8;;https://example.com\x1b\\bar\x1b]8;;\x1b\\\n\x1b]8;;https://example.com\x1b\\baz\x1b]8;;\x1b\\",
+			4,
+		},
 		// Handle words with colors sequence inside the word
 		{
 			"foo b\x1b[31mbar\x1b[0mr baz",
@@ -110,14 +124,14 @@ func TestWrap(t *testing.T) {
 		},
 		// Handle mixed wide and short characters with color
 		{
-			"敏捷 A \x1b31mquick 的狐狸 fox 跳\x1b0m过 jumps over a lazy 了一只懒狗 dog。",
-			"敏捷 A \x1b31mquick\n的狐狸 fox\n跳\x1b0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
+			"敏捷 A \x1b[31mquick 的狐狸 fox 跳\x1b[0m过 jumps over a lazy 了一只懒狗 dog。",
+			"敏捷 A \x1b[31mquick\n的狐狸 fox\n跳\x1b[0m过 jumps\nover a lazy\n了一只懒狗\ndog。",
 			12,
 		},
 		// Handle mixed wide and short characters with color at both ends
 		{
-			"\x1b31m敏捷 A quick 的狐狸 fox 跳过 jumps over a lazy 了一只懒狗 dog。\x1b0m",
-			"\x1b31m敏捷 A quick\n的狐狸 fox\n跳过 jumps\nover a lazy\n了一只懒狗\ndog。\x1b0m",
+			"\x1b[31m敏捷 A quick 的狐狸 fox 跳过 jumps over a lazy 了一只懒狗 dog。\x1b[0m",
+			"\x1b[31m敏捷 A quick\n的狐狸 fox\n跳过 jumps\nover a lazy\n了一只懒狗\ndog。\x1b[0m",
 			12,
 		},
 	}
@@ -137,10 +151,248 @@ func TestWrap(t *testing.T) {
 	}
 }
 
+func TestWrapLineBreakUnicode(t *testing.T) {
+	cases := []struct {
+		Input, Output string
+		Lim           int
+	}{
+		// Break after hyphens
+		{
+			"well-known foo-bar",
+			"well-\nknown\nfoo-bar",
+			8,
+		},
+		// Break after slashes
+		{
+			"http://example.com/foo/bar",
+			"http://\nexample.com/\nfoo/bar",
+			12,
+		},
+		// Don't break before closing punctuation
+		{
+			"foo bar !",
+			"foo\nbar !",
+			7,
+		},
+		// Don't break at non-breaking spaces
+		{
+			"foo bar\u00a0baz",
+			"foo\nbar\u00a0baz",
+			8,
+		},
+		// Mandatory breaks
+		{
+			"foo\u2028bar\fbaz",
+			"foo\nbar\nbaz",
+			8,
+		},
+		// CR LF is a single break
+		{
+			"foo\r\nbar\r\n\r\nbaz",
+			"foo\nbar\n\nbaz",
+			8,
+		},
+		{
+			"\u200b\r\nfoo",
+			"\u200b\nfoo",
+			10,
+		},
+		// Handle words with colors sequence inside the word
+		{
+			"foo-b\x1b[31mbar\x1b[0mr baz",
+			"foo-\nb\x1b[31mbar\x1b[0mr\nbaz",
+			6,
+		},
+		// Handle chinese, no break before the full stop
+		{
+			"一只敏捷的狐狸跳过了一只懒狗。",
+			"一只敏捷的狐\n狸跳过了一只\n懒狗。",
+			12,
+		},
+		{
+			"一只敏捷的狐。狸跳过了一只懒狗。",
+			"一只敏捷的\n狐。狸跳过了\n一只懒狗。",
+			12,
+		},
+	}
+
+	for i, tc := range cases {
+		actual, lines := Wrap(tc.Input, tc.Lim, WrapLineBreak(LineBreakUnicode))
+		if actual != tc.Output {
+			t.Fatalf("Case %d Input:\n\n`%s`\n\nExpected Output:\n\n`%s`\n\nActual Output:\n\n`%s`",
+				i, tc.Input, tc.Output, actual)
+		}
+
+		expected := len(strings.Split(tc.Output, "\n"))
+		if expected != lines {
+			t.Fatalf("Case %d Nb lines mismatch\nExpected:%d\nActual:%d",
+				i, expected, lines)
+		}
+	}
+}
+
+func TestWrapFitOptimal(t *testing.T) {
+	cases := []struct {
+		input, output string
+		lim           int
+		indent, pad   string
+		align         Alignment
+	}{
+		{
+			"aaa bb cc ddddd",
+			"aaa\nbb cc\nddddd",
+			6, "", "",
+			NoAlign,
+		},
+		{
+			"The Lorem ipsum text is typically composed of pseudo-Latin words. It is commonly used as placeholder text to examine or demonstrate the visual effects of various graphic design.",
+			`  The Lorem ipsum text
+> is typically composed
+> of pseudo-Latin words.
+> It is commonly used as
+> placeholder text to examine
+> or demonstrate the visual
+> effects of various graphic
+> design.`,
+			30, "  ", "> ",
+			NoAlign,
+		},
+		// Alignment on top of the optimal fit, with escapes and wide characters
+		{
+			"一只敏捷的\x1b[31m狐狸跳过了一只懒狗\x1b[0m。 foo bar",
+			"  一只敏捷\n" +
+				"> 的\x1b[31m狐狸跳\x1b[0m\n" +
+				">\x1b[31m 过了一只\x1b[0m\n" +
+				">\x1b[31m   懒狗\x1b[0m。\n" +
+				">  foo bar",
+			10, "  ", ">",
+			AlignRight,
+		},
+	}
+
+	for i, tc := range cases {
+		actual, lines := Wrap(tc.input, tc.lim,
+			WrapIndent(tc.indent), WrapPad(tc.pad), WrapAlign(tc.align), WrapFitting(FitOptimal))
+		if actual != tc.output {
+			t.Fatalf("Case %d Input:\n\n`%s`\n\nExpected Output:\n`\n%s`\n\nActual Output:\n`\n%s\n%s`",
+				i, tc.input, tc.output,
+				"|"+strings.Repeat("-", tc.lim-2)+"|",
+				actual)
+		}
+
+		expected := len(strings.Split(tc.output, "\n"))
+		if expected != lines {
+			t.Fatalf("Case %d Nb lines mismatch\nExpected:%d\nActual:%d",
+				i, expected, lines)
+		}
+	}
+}
+
+func TestWrapTabWidth(t *testing.T) {
+	cases := []struct {
+		input, output string
+		lim           int
+		tabWidth      int
+	}{
+		{"a\tb\tc", "a   b   c", 20, 4},
+		{"a\tb\tc", "a       b       c", 20, 8},
+		{"a\tb\tc", "abc", 20, 0},
+		// tab stops are based on the column in the original line
+		{"\tfoo bar\n\tbaz", "    foo\nbar\n    baz", 8, 4},
+		{"abc\tdefgh", "abc\ndefgh", 6, 4},
+		{"abc\r\n\tx", "abc\r\n    x", 20, 4},
+	}
+
+	for i, tc := range cases {
+		actual, _ := Wrap(tc.input, tc.lim, WrapTabWidth(tc.tabWidth))
+		if actual != tc.output {
+			t.Fatalf("Case %d Input:\n\n`%s`\n\nExpected Output:\n`\n%s`\n\nActual Output:\n`\n%s`",
+				i, tc.input, tc.output, actual)
+		}
+	}
+}
+
+func TestWrapHyphenation(t *testing.T) {
+	cases := []struct {
+		input, output string
+		lim           int
+		hyphenator    *Hyphenator
+		fitting       Fitting
+	}{
+		{
+			"The hyphenation algorithm is quite \x1b[31mremarkable\x1b[0m indeed",
+			"The hyphena-\ntion algo-\nrithm is\nquite \x1b[31mre-\nmarkable\x1b[0m in-\ndeed",
+			12, EnglishHyphenator(), FitGreedy,
+		},
+		{
+			"The hyphenation algorithm is quite remarkable indeed",
+			"The hyphen-\nation al-\ngorithm is\nquite re-\nmarkable in-\ndeed",
+			12, EnglishHyphenator(), FitOptimal,
+		},
+		// the hyphen is counted when splitting the words longer than the line
+		{
+			"internationalization is hard",
+			"in-\nter-\nna-\ntio\nnal-\niza-\ntion\nis\nhard",
+			4, EnglishHyphenator(), FitOptimal,
+		},
+		// only the soft hyphens present in the text
+		{
+			"A super\u00adcali\u00adfragilistic word",
+			"A super-\ncalifragil\nistic word",
+			10, nil, FitGreedy,
+		},
+		// unused soft hyphens are removed
+		{
+			"A super\u00adcali word",
+			"A supercali word",
+			30, nil, FitGreedy,
+		},
+	}
+
+	for i, tc := range cases {
+		actual, lines := Wrap(tc.input, tc.lim, WrapHyphenation(tc.hyphenator), WrapFitting(tc.fitting))
+		if actual != tc.output {
+			t.Fatalf("Case %d Input:\n\n`%s`\n\nExpected Output:\n`\n%s`\n\nActual Output:\n`\n%s\n%s`",
+				i, tc.input, tc.output,
+				"|"+strings.Repeat("-", tc.lim-2)+"|",
+				actual)
+		}
+
+		expected := len(strings.Split(tc.output, "\n"))
+		if expected != lines {
+			t.Fatalf("Case %d Nb lines mismatch\nExpected:%d\nActual:%d",
+				i, expected, lines)
+		}
+	}
+}
+
+func TestWrapHyphenationWidth(t *testing.T) {
+	inputs := []string{
+		"internationalization is hard",
+		"supercalifragilistic",
+		"The hyphenation algorithm is quite \x1b[31mremarkable\x1b[0m indeed",
+		"一只敏捷的 hyphenation 狐狸",
+	}
+
+	for _, input := range inputs {
+		for _, fitting := range []Fitting{FitGreedy, FitOptimal} {
+			for lim := 2; lim <= 12; lim++ {
+				actual, _ := Wrap(input, lim, WrapHyphenation(EnglishHyphenator()), WrapFitting(fitting))
+				for _, line := range strings.Split(actual, "\n") {
+					if Len(line) > lim {
+						t.Fatalf("Input %q, fitting %d, width %d: line %q is too long in:\n%s",
+							input, fitting, lim, line, actual)
+					}
+				}
+			}
+		}
+	}
+}
+
 func BenchmarkWrap(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		Wrap("敏捷 A \x1b31mquick 的狐狸 fox 跳\x1b0m过 jumps over a lazy 了一只懒狗 dog。", 12)
+		Wrap("敏捷 A \x1b[31mquick 的狐狸 fox 跳\x1b[0m过 jumps over a lazy 了一只懒狗 dog。", 12)
 	}
 }
 
@@ -240,6 +492,20 @@ func TestWrapWithPadIndent(t *testing.T) {
       .`,
 			58, "  ", "      ",
 		},
+		// Extended SGR attributes are kept across the line breaks
+		{
+			"foo \x1b[4:3;53;58;5;100mbar baz\x1b[0m",
+			"  foo \x1b[4:3;53;58;5;100mbar\x1b[0m\n" +
+				"  \x1b[4:3;53;58;5;100mbaz\x1b[0m",
+			9, "  ", "  ",
+		},
+		// The style left by the padding doesn't leak in the content
+		{
+			"foo \x1b[1;31mbar baz\x1b[0m",
+			"\x1b[2m| \x1b[0mfoo \x1b[1;31mbar\x1b[0m\n" +
+				"\x1b[2m| \x1b[0;1;31mbaz\x1b[0m",
+			9, "\x1b[2m| ", "\x1b[2m| ",
+		},
 	}
 
 	for i, tc := range cases {
@@ -316,6 +582,18 @@ func TestWrapWithPadIndentAlign(t *testing.T) {
 			63, "\x1b[31m狐狸跳过\x1b[0m", "\x1b[31m狐\x1b[0m",
 			AlignCenter,
 		},
+		// Justify, except the last line of each paragraph
+		{
+			"The Lorem ipsum text is typically composed of pseudo-Latin words. It is commonly used as placeholder text.\nThe end.",
+			"<indent>The  Lorem  ipsum  text  is\n" +
+				"<pad>typically      composed     of\n" +
+				"<pad>pseudo-Latin   words.   It  is\n" +
+				"<pad>commonly  used  as placeholder\n" +
+				"<pad>text.\n" +
+				"<pad>The end.",
+			35, "<indent>", "<pad>",
+			AlignJustify,
+		},
 		// handle too long indentation
 		{
 			"The Lorem ipsum text is typically composed of pseudo-Latin words. It is commonly used as placeholder text to examine or demonstrate the visual effects of various graphic design.",
@@ -346,6 +624,15 @@ func TestWrapWithPadIndentAlign(t *testing.T) {
 			20, "<indentindentindent>", "<padpadpadpadpadpad>",
 			AlignLeft,
 		},
+		// close and reopen hyperlinks around the padding
+		{
+			"see \x1b]8;;https://example.com\x1b\\the \x1b[1mdocs\x1b[0m\x1b]8;;\x1b\\",
+			"<indent>see\n" +
+				"<pad>\x1b]8;;https://example.com\x1b\\the\x1b]8;;\x1b\\\n" +
+				"<pad>\x1b]8;;https://example.com\x1b\\\x1b[1mdocs\x1b[0m\x1b]8;;\x1b\\",
+			11, "<indent>", "<pad>",
+			AlignLeft,
+		},
 	}
 
 	for i, tc := range cases {
@@ -431,6 +718,29 @@ func TestSplitWord(t *testing.T) {
 			4,
 			"快\x1b[31m檢", "什麼\x1b[0m望對",
 		},
+		// Handle escapes that don't end with 'm'
+		{
+			"\x1b[2Kfoo\x1b]0;title\x07barHoy",
+			4,
+			"\x1b[2Kfoo\x1b]0;title\x07b", "arHoy",
+		},
+		// Don't split grapheme clusters
+		{
+			"e\u0301e\u0301e\u0301",
+			2,
+			"e\u0301e\u0301", "e\u0301",
+		},
+		{
+			"a\U0001f468\u200d\U0001f469\u200d\U0001f467b",
+			2,
+			"a", "\U0001f468\u200d\U0001f469\u200d\U0001f467b",
+		},
+		// Take at least one grapheme cluster
+		{
+			"一只",
+			1,
+			"一", "只",
+		},
 	}
 
 	for i, tc := range cases {
@@ -472,6 +782,11 @@ func TestSegmentLines(t *testing.T) {
 				" ", "where", "   ", "一", "只", " ", "and", " ", "English", " ", "混",
 				"合", "了", "。"},
 		},
+		// Grapheme clusters are kept whole.
+		{
+			"caf\u0065\u0301 \U0001f468\u200d\U0001f469\u200d\U0001f467\U0001f1eb\U0001f1f7",
+			[]string{"cafe\u0301", " ", "\U0001f468\u200d\U0001f469\u200d\U0001f467", "\U0001f1eb\U0001f1f7"},
+		},
 	}
 
 	for i, tc := range cases {
@@ -0,0 +1,158 @@
+package text
+
+import (
+	"bytes"
+	"io"
+)
+
+// WrapWriter is an io.Writer that wrap the text written to it, like Wrap(),
+// and write the result to an underlying io.Writer.
+//
+// The text can be written in arbitrary chunks, even in the middle of an escape
+// sequence or a UTF-8 encoded character. The wrapped lines are written as soon
+// as they can't be changed by the text that follows, that is at the end of
+// each line of text, or earlier with the greedy fitting and the simple line
+// breaking. Flush must be called after the last write.
+type WrapWriter struct {
+	w       io.Writer
+	wrapper *wrapper
+	// fallback is the whole output when the wrapping is not possible
+	fallback string
+	// pending is the part of the current line of text not wrapped yet,
+	// starting at the column col. skip is the length of its beginning
+	// already wrapped once the tabs expanded, when the wrapping stopped in
+	// the middle of an expanded tab.
+	pending []byte
+	col     int
+	skip    int
+	err     error
+}
+
+// NewWrapWriter create a WrapWriter writing the text wrapped for the given line
+// size into w. The same options as Wrap() are accepted.
+func NewWrapWriter(w io.Writer, lineWidth int, opts ...WrapOption) *WrapWriter {
+	wrapOpts := allWrapOpts(opts)
+	ww := &WrapWriter{w: w}
+
+	switch {
+	case lineWidth <= 0:
+		// nothing to write
+	case Len(wrapOpts.pad) >= lineWidth:
+		// padding is too wide, fallback rendering
+		ww.fallback = padFallback(lineWidth)
+	default:
+		ww.wrapper = newWrapper(lineWidth, wrapOpts, false)
+	}
+
+	return ww
+}
+
+// Write wrap the given text. Complete lines are written to the underlying
+// writer, the rest is kept until more text is written or Flush is called.
+func (ww *WrapWriter) Write(p []byte) (int, error) {
+	if ww.err != nil {
+		return 0, ww.err
+	}
+	if ww.wrapper == nil {
+		return len(p), nil
+	}
+
+	ww.pending = append(ww.pending, p...)
+
+	for {
+		i := bytes.IndexByte(ww.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := ww.pending[:i]
+		if ww.wrapper.opts.lineBreak == LineBreakUnicode {
+			// "\r\n" is a single break
+			line = bytes.TrimSuffix(line, []byte("\r"))
+		}
+		ww.wrapLines(ww.remaining(line))
+		ww.wrapper.breakLine()
+		ww.pending = ww.pending[i+1:]
+		ww.col = 0
+		ww.skip = 0
+	}
+
+	if len(ww.pending) > 0 && ww.wrapper.canWrapPartial() {
+		line := ww.remaining(ww.pending)
+		rest := ww.wrapper.wrapLine(line, false)
+		ww.consume(len(line) - len(rest))
+	}
+
+	// the input has been accepted, even if the output failed
+	if err := ww.writeOut(); err != nil {
+		return len(p), err
+	}
+	return len(p), nil
+}
+
+// Flush wrap and write the remaining text. Like with Wrap(), the last line
+// doesn't end with a line break.
+func (ww *WrapWriter) Flush() error {
+	if ww.err != nil {
+		return ww.err
+	}
+
+	if ww.wrapper == nil {
+		_, err := io.WriteString(ww.w, ww.fallback)
+		ww.fallback = ""
+		return err
+	}
+
+	ww.wrapLines(ww.remaining(ww.pending))
+	ww.pending = nil
+	ww.col = 0
+	ww.skip = 0
+
+	return ww.writeOut()
+}
+
+// remaining return the part of a line of text not wrapped yet, with the tabs
+// expanded.
+func (ww *WrapWriter) remaining(line []byte) string {
+	return expandTabs(string(line), ww.col, ww.wrapper.opts.tabWidth)[ww.skip:]
+}
+
+// consume drop the beginning of the pending text, once wrapped. n is the
+// length of that beginning in the remaining text, with the tabs expanded.
+func (ww *WrapWriter) consume(n int) {
+	n += ww.skip
+	tabWidth := ww.wrapper.opts.tabWidth
+
+	raw, expanded := 0, 0
+	walkGraphemes(string(ww.pending), func(str string, _ bool, width int) bool {
+		size := len(str)
+		if str == "\t" {
+			width = tabStopWidth(ww.col, tabWidth)
+			size = width
+		}
+		if expanded+size > n {
+			return false
+		}
+		raw += len(str)
+		expanded += size
+		ww.col += width
+		return true
+	})
+
+	ww.pending = ww.pending[raw:]
+	ww.skip = n - expanded
+}
+
+// wrapLines wrap the remaining of a complete line of text.
+func (ww *WrapWriter) wrapLines(text string) {
+	for _, line := range splitLines(text, ww.wrapper.opts.lineBreak) {
+		ww.wrapper.wrapLine(line, true)
+	}
+}
+
+// writeOut write the wrapped lines to the underlying writer.
+func (ww *WrapWriter) writeOut() error {
+	_, err := io.WriteString(ww.w, ww.wrapper.out.String())
+	ww.wrapper.out.Reset()
+	ww.err = err
+	return err
+}
@@ -0,0 +1,135 @@
+package text
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapWriter(t *testing.T) {
+	inputs := []string{
+		"",
+		"foo",
+		"foo\n",
+		"\n\nfoo\n\n",
+		"foo\r\nbar\r\n\r\nbaz",
+		"aaa bbb ccc",
+		"The \x1b[1mLorem ipsum\x1b[0m text is typically composed of pseudo-Latin words.\n" +
+			"It is commonly used as \x1b[3mplaceholder\x1b[0m text to examine or demonstrate\n\n" +
+			"the \x1b[9mvisual effects\x1b[0m of various graphic design.",
+		"一只 A Quick \x1b[31m敏捷的狐 Fox 狸跳过了\x1b[0mDog一只懒狗。",
+		"a veryveryveryveryverylongword\tand \x1b]8;;http://example.com\x1b\\a link\x1b]8;;\x1b\\ to follow",
+		"  leading spaces and    inner spaces   ",
+		"aaa bbb ccc ddd\teee\tf ggg\thhh\n\ti\tjjj kkk\tl",
+		"a\r\n\tb",
+		"\u200b\r\nfoo",
+	}
+
+	options := [][]WrapOption{
+		nil,
+		{WrapPad("> ")},
+		{WrapIndent("\x1b[34m<-indent-> \x1b[0m"), WrapPad("\x1b[33m<-pad-> \x1b[0m")},
+		{WrapIndent("  "), WrapPad(" "), WrapAlign(AlignCenter)},
+		{WrapAlign(AlignJustify)},
+		{WrapFitting(FitOptimal)},
+		{WrapLineBreak(LineBreakUnicode)},
+		{WrapHyphenation(EnglishHyphenator())},
+	}
+
+	for _, input := range inputs {
+		for i, opts := range options {
+			for _, width := range []int{4, 7, 10, 20, 40} {
+				expected, _ := Wrap(input, width, opts...)
+
+				// write the input in chunks of various sizes, to split the
+				// escape sequences and the runes at different places
+				for _, size := range []int{1, 2, 3, 7, len(input) + 1} {
+					var buf bytes.Buffer
+					w := NewWrapWriter(&buf, width, opts...)
+					for start := 0; start < len(input); start += size {
+						end := start + size
+						if end > len(input) {
+							end = len(input)
+						}
+						n, err := w.Write([]byte(input[start:end]))
+						assert.NoError(t, err)
+						assert.Equal(t, end-start, n)
+					}
+					assert.NoError(t, w.Flush())
+
+					if !assert.Equal(t, expected, buf.String(), "input %q options %d width %d chunk %d", input, i, width, size) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestWrapWriterEarlyFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWrapWriter(&buf, 10, WrapPad("> "))
+
+	_, _ = w.Write([]byte("The quick brown fox"))
+	assert.Equal(t, "> The\n> quick", buf.String())
+
+	_, _ = w.Write([]byte(" jumps\nover"))
+	assert.Equal(t, "> The\n> quick\n> brown\n> fox\n> jumps\n", buf.String())
+
+	assert.NoError(t, w.Flush())
+	expected, _ := Wrap("The quick brown fox jumps\nover", 10, WrapPad("> "))
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWrapWriterPadTooWide(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWrapWriter(&buf, 3, WrapPad("12345"))
+	_, _ = w.Write([]byte("foo"))
+	assert.NoError(t, w.Flush())
+
+	expected, _ := Wrap("foo", 3, WrapPad("12345"))
+	assert.Equal(t, expected, buf.String())
+	assert.True(t, strings.HasPrefix(buf.String(), "⭬⭬⭬\n"))
+}
+
+func TestWrapWriterLongLine(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWrapWriter(&buf, 20, WrapPad("> "))
+
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		chunk := "foo\tbar baz "
+		input.WriteString(chunk)
+		_, err := w.Write([]byte(chunk))
+		assert.NoError(t, err)
+		// only the part not wrapped yet is kept
+		assert.True(t, len(w.pending) < 20, "pending %q", w.pending)
+	}
+	assert.NoError(t, w.Flush())
+
+	expected, _ := Wrap(input.String(), 20, WrapPad("> "))
+	assert.Equal(t, expected, buf.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("failed")
+}
+
+func TestWrapWriterError(t *testing.T) {
+	w := NewWrapWriter(failingWriter{}, 10)
+
+	// the input is accepted, even if it can't be written
+	n, err := w.Write([]byte("foo bar baz\n"))
+	assert.Error(t, err)
+	assert.Equal(t, 12, n)
+
+	n, err = w.Write([]byte("foo"))
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+	assert.Error(t, w.Flush())
+}